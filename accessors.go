@@ -0,0 +1,173 @@
+// CRC: crc-Accessor.md
+// Spec: api.md, resolver.md
+package changetracker
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// accessorKey identifies a compiled path element: a dynamic type and a field,
+// map key, or method name.
+type accessorKey struct {
+	typ  reflect.Type
+	name string
+	call bool // method call (Call) rather than field or map key (Get)
+}
+
+// accessor is a compiled path element for one dynamic type.
+// It only covers the successful case; anything it cannot handle (nil pointers,
+// missing map keys, unusual types) goes through the reflective slow path,
+// which also produces the errors.
+// CRC: crc-Accessor.md
+type accessor struct {
+	typ    reflect.Type // dynamic type the accessor was compiled for
+	derefs int          // pointer levels to dereference
+	field  []int        // struct field index chain
+	mapKey bool         // string-keyed map lookup
+	method int          // method index, or -1
+	onPtr  bool         // method has a pointer receiver
+}
+
+// accessorCache holds *accessor per accessorKey (nil when there is no fast path),
+// shared by all trackers.
+var accessorCache sync.Map
+
+// accessorFor returns the compiled accessor for name on typ, or nil to use the slow path.
+func accessorFor(typ reflect.Type, name string, call bool) *accessor {
+	key := accessorKey{typ, name, call}
+	if acc, ok := accessorCache.Load(key); ok {
+		return acc.(*accessor)
+	}
+	acc, _ := accessorCache.LoadOrStore(key, compileAccessor(typ, name, call))
+	return acc.(*accessor)
+}
+
+// compileAccessor resolves name on typ once. Returns nil if there is no fast path.
+func compileAccessor(typ reflect.Type, name string, call bool) *accessor {
+	acc := &accessor{typ: typ, method: -1}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+		acc.derefs++
+	}
+	if call {
+		m, ok := typ.MethodByName(name)
+		if !ok && acc.derefs > 0 {
+			// Dereferenced values are addressable, so pointer methods apply
+			m, ok = reflect.PointerTo(typ).MethodByName(name)
+			acc.onPtr = true
+		}
+		// Method types include the receiver
+		if !ok || m.Type.NumOut() == 0 {
+			return nil
+		}
+		if in := m.Type.NumIn() - 1; in != 0 && !(in == 1 && m.Type.IsVariadic()) {
+			return nil
+		}
+		acc.method = m.Index
+		return acc
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		sf := fieldsOf(typ).byName[name]
+		if sf == nil {
+			return nil
+		}
+		acc.field = sf.index
+	case reflect.Map:
+		if !reflect.TypeFor[string]().AssignableTo(typ.Key()) {
+			return nil
+		}
+		acc.mapKey = true
+	default:
+		return nil
+	}
+	return acc
+}
+
+// deref dereferences obj's pointers. Returns false for nil pointers.
+func (a *accessor) deref(obj any) (reflect.Value, bool) {
+	rv := reflect.ValueOf(obj)
+	for range a.derefs {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+	return rv, true
+}
+
+// get returns the field or map value for name. Returns false to use the slow path.
+func (a *accessor) get(obj any, name string) (any, bool) {
+	rv, ok := a.deref(obj)
+	if !ok {
+		return nil, false
+	}
+	var val reflect.Value
+	if a.mapKey {
+		val = rv.MapIndex(reflect.ValueOf(name))
+	} else {
+		val, _ = rv.FieldByIndexErr(a.field)
+	}
+	if !val.IsValid() || !val.CanInterface() {
+		return nil, false
+	}
+	return val.Interface(), true
+}
+
+// call invokes the method and returns its first result. Returns false to use the slow path.
+func (a *accessor) call(obj any) (any, bool) {
+	rv, ok := a.deref(obj)
+	if !ok {
+		return nil, false
+	}
+	if a.onPtr {
+		rv = rv.Addr()
+	}
+	return rv.Method(a.method).Call(nil)[0].Interface(), true
+}
+
+// get resolves path element i of v on obj. With the default resolver, the
+// accessor is remembered on the variable so unchanged types skip the cache lookup.
+func (v *Variable) get(ctx context.Context, i int, obj any, elem any) (any, error) {
+	t := v.tracker
+	if name, ok := elem.(string); ok && t.Resolver == Resolver(t) {
+		if acc := v.accessor(i, reflect.TypeOf(obj), name, false); acc != nil {
+			if val, ok := acc.get(obj, name); ok {
+				return val, nil
+			}
+		}
+	}
+	return t.resolveGet(ctx, obj, elem)
+}
+
+// call invokes the getter method of path element i of v on obj, like get.
+func (v *Variable) call(ctx context.Context, i int, obj any, methodName string) (any, error) {
+	t := v.tracker
+	if t.Resolver == Resolver(t) {
+		if acc := v.accessor(i, reflect.TypeOf(obj), methodName, true); acc != nil {
+			if val, ok := acc.call(obj); ok {
+				return val, nil
+			}
+		}
+	}
+	return t.resolveCall(ctx, obj, methodName)
+}
+
+// accessor returns the accessor for path element i on typ, remembering it on v.
+func (v *Variable) accessor(i int, typ reflect.Type, name string, call bool) *accessor {
+	if i < len(v.accessors) {
+		if acc := v.accessors[i]; acc != nil && acc.typ == typ {
+			return acc
+		}
+	}
+	acc := accessorFor(typ, name, call)
+	if acc != nil {
+		if len(v.accessors) < len(v.Path) {
+			v.accessors = make([]*accessor, len(v.Path))
+		}
+		v.accessors[i] = acc
+	}
+	return acc
+}
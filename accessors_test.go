@@ -0,0 +1,189 @@
+package changetracker
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// Accessor Cache Tests (test-Accessor.md)
+// ============================================================================
+
+// uncachedResolver is the default resolver without compiled accessors.
+type uncachedResolver struct {
+	*Tracker
+}
+
+func (r uncachedResolver) Get(obj any, pathElement any) (any, error) {
+	return r.getSlow(obj, pathElement)
+}
+
+func (r uncachedResolver) Call(obj any, methodName string) (any, error) {
+	return r.callSlow(obj, methodName)
+}
+
+type Shape interface{ Area() int }
+
+type Square struct{ Side int }
+
+func (s Square) Area() int { return s.Side * s.Side }
+
+type Rect struct{ W, H int }
+
+func (r *Rect) Area() int { return r.W * r.H }
+
+type Holder struct {
+	Shape  Shape
+	Any    any
+	Labels map[string]int
+	ByInt  map[int]string
+	Ptr    **Counter
+	*Inner
+	Sum func(...int) int
+}
+
+// AC1.1: cached lookups match the slow path for values and errors
+func TestAccessors_MatchSlowPath(t *testing.T) {
+	tr := NewTracker()
+	slow := uncachedResolver{tr}
+	counter := &Counter{value: 3}
+	objs := []any{
+		&Person{Name: "Alice", Age: 30},
+		Person{Name: "Bob"},
+		&Counter{value: 4},
+		Counter{value: 5},
+		&counter,
+		&Holder{Shape: Square{2}, Any: &Rect{2, 3}, Labels: map[string]int{"a": 1}, ByInt: map[int]string{1: "x"}, Inner: &Inner{value: 2}},
+		&Holder{Inner: &Inner{value: 1}},
+		map[string]any{"Name": "m"},
+		(*Person)(nil),
+		&Tagged{FirstName: "Ada", ID: 1},
+		[]int{1, 2},
+	}
+	names := []string{"Name", "Age", "Missing", "Shape", "Any", "Labels", "ByInt", "Ptr", "Inner", "value", "firstName", "id", "Secret", "a"}
+	methods := []string{"Value", "Count", "SetValue", "Missing", "Area", "GetValue"}
+	for _, obj := range objs {
+		for _, name := range names {
+			got, gotErr := tr.Get(obj, name)
+			want, wantErr := slow.Get(obj, name)
+			if !reflect.DeepEqual(got, want) || (gotErr == nil) != (wantErr == nil) ||
+				(gotErr != nil && gotErr.Error() != wantErr.Error()) {
+				t.Errorf("Get(%T, %q): got %v (%v), want %v (%v)", obj, name, got, gotErr, want, wantErr)
+			}
+		}
+		for _, name := range methods {
+			got, gotErr := tr.Call(obj, name)
+			want, wantErr := slow.Call(obj, name)
+			if !reflect.DeepEqual(got, want) || (gotErr == nil) != (wantErr == nil) ||
+				(gotErr != nil && gotErr.Error() != wantErr.Error()) {
+				t.Errorf("Call(%T, %q): got %v (%v), want %v (%v)", obj, name, got, gotErr, want, wantErr)
+			}
+		}
+	}
+}
+
+// AC1.2: dynamic types behind interfaces resolve per concrete type
+func TestAccessors_DynamicTypes(t *testing.T) {
+	tr := NewTracker()
+	h := &Holder{Shape: Square{3}}
+	root := tr.CreateVariable(h, 0, "", nil)
+	area := tr.CreateVariable(nil, root.ID, "Shape.Area()?access=r", nil)
+	if v, _ := area.Get(); v != 9 {
+		t.Errorf("expected 9, got %v", v)
+	}
+	h.Shape = &Rect{2, 5}
+	tr.DetectChanges()
+	if v, _ := area.Get(); v != 10 {
+		t.Errorf("expected 10 after type change, got %v", v)
+	}
+	h.Shape = nil
+	if _, err := area.Get(); err == nil {
+		t.Error("expected error for nil interface")
+	}
+}
+
+// AC1.3: accessors are compiled once per type and element
+func TestAccessors_Cached(t *testing.T) {
+	typ := reflect.TypeFor[*Person]()
+	a := accessorFor(typ, "Name", false)
+	if a == nil || a != accessorFor(typ, "Name", false) {
+		t.Errorf("expected one cached accessor, got %v", a)
+	}
+	if accessorFor(typ, "Missing", false) != nil {
+		t.Error("expected no fast path for a missing field")
+	}
+}
+
+// AC1.4: remembered accessors follow path and resolver changes
+func TestAccessors_VariableMemo(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	v := tr.CreateVariable(nil, root.ID, "Name", nil)
+	v.SetProperty("path", "Age")
+	if val, err := v.Get(); err != nil || val != 30 {
+		t.Errorf("expected 30 after path change, got %v (%v)", val, err)
+	}
+	tr.Resolver = &upperResolver{tr}
+	v.SetProperty("path", "Name")
+	if val, err := v.Get(); err != nil || val != "ALICE" {
+		t.Errorf("expected custom resolver result, got %v (%v)", val, err)
+	}
+}
+
+// upperResolver upper-cases string results of the default resolver.
+type upperResolver struct {
+	*Tracker
+}
+
+func (r *upperResolver) Get(obj any, pathElement any) (any, error) {
+	val, err := r.Tracker.Get(obj, pathElement)
+	if s, ok := val.(string); ok {
+		return strings.ToUpper(s), err
+	}
+	return val, err
+}
+
+// newBenchTree creates a 10,001-variable tree: a root and 2,500 items, each with
+// a field, a getter method and a map key child.
+func newBenchTree(tr *Tracker) {
+	type item struct {
+		Person *Person
+		Tags   map[string]string
+	}
+	items := make([]*item, 2500)
+	for i := range items {
+		items[i] = &item{Person: &Person{Name: "p", Age: i}, Tags: map[string]string{"kind": "k"}}
+	}
+	root := tr.CreateVariable(items, 0, "", nil)
+	for i := range items {
+		v := tr.CreateVariable(nil, root.ID, strconv.Itoa(i), nil)
+		tr.CreateVariable(nil, v.ID, "Person.Name", nil)
+		tr.CreateVariable(nil, v.ID, "Person.GetName()?access=r", nil)
+		tr.CreateVariable(nil, v.ID, "Tags.kind", nil)
+	}
+}
+
+// BenchmarkDetectChanges10k compares detection over 10,000 variables with and
+// without compiled accessors.
+func BenchmarkDetectChanges10k(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		resolve func(*Tracker) Resolver
+	}{
+		{"cached", func(tr *Tracker) Resolver { return tr }},
+		{"uncached", func(tr *Tracker) Resolver { return uncachedResolver{tr} }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			tr := NewTracker()
+			tr.Resolver = bc.resolve(tr)
+			newBenchTree(tr)
+			b.ReportAllocs()
+			for b.Loop() {
+				tr.DetectChanges()
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// CRC: crc-BudgetedDetection.md
+// Spec: api.md
+package changetracker
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+)
+
+// DetectChangesBudget performs part of a detection pass, stopping once
+// maxDuration has elapsed on the tracker's Clock or ctx is done, and resumes the same pass on the
+// next call. The pass is depth-first, visiting higher-priority roots and
+// children first. Each call first checks everything marked dirty (see
+// DetectDirty) and always checks at least one variable of the pass unless
+// ctx is done, so every subtree is reached within a bounded number of calls.
+// Reads use ctx as in DetectChangesContext; once ctx is done nothing more is
+// checked and the dirty marks are kept for the next call.
+// Changes are recorded, grouped into one undo step and sent to subscriptions
+// per call. Returns whether any value changed and whether the pass completed;
+// the next call then starts a new pass. Variables created during a pass are
+// not visited by it.
+// Sequence: seq-detect-changes-budget.md
+func (t *Tracker) DetectChangesBudget(ctx context.Context, maxDuration time.Duration) (changed, complete bool) {
+	deadline := t.now().Add(maxDuration)
+	marked := t.takeMarks()
+	defer t.endPass()
+	t.ctx = ctx
+	if h := t.history; h != nil {
+		h.Begin()
+		defer h.End()
+	}
+	changed = t.checkMarked(marked)
+	if t.walk == nil {
+		t.walk = t.byPriority(slices.Collect(maps.Keys(t.rootIDs)))
+	}
+	for len(t.walk) > 0 && ctx.Err() == nil {
+		id := t.walk[len(t.walk)-1]
+		t.walk = t.walk[:len(t.walk)-1]
+		changed = t.checkStep(id) || changed
+		if !t.now().Before(deadline) {
+			break
+		}
+	}
+	complete = len(t.walk) == 0
+	if complete {
+		t.walk = nil
+	}
+	if ctx.Err() != nil {
+		// Marked variables may have been skipped
+		t.restoreMarks()
+	}
+	t.notify()
+	return changed, complete
+}
+
+// checkStep checks one variable of a budgeted pass, as checkVariable does
+// without recursing, and queues its children.
+func (t *Tracker) checkStep(id int64) bool {
+	v := t.variables[id]
+	if v == nil || !v.Active {
+		return false
+	}
+	if !t.due(v) {
+		t.walk = append(t.walk, t.byPriority(t.scheduled(v))...)
+		return false
+	}
+	changed := false
+	if v.IsReadable() {
+		v.Error = nil
+		changed = t.refresh(v)
+		if !changed && t.quiet(v) {
+			return false
+		}
+	}
+	t.walk = append(t.walk, t.byPriority(slices.Clone(v.ChildIDs))...)
+	return changed
+}
+
+// byPriority orders IDs for the walk stack, so the highest value priority is
+// popped first and the lowest ID breaks ties.
+func (t *Tracker) byPriority(ids []int64) []int64 {
+	slices.SortStableFunc(ids, func(a, b int64) int {
+		va, vb := t.variables[a], t.variables[b]
+		if c := cmp.Compare(va.ValuePriority, vb.ValuePriority); c != 0 {
+			return c
+		}
+		return cmp.Compare(b, a)
+	})
+	return ids
+}
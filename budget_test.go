@@ -0,0 +1,132 @@
+package changetracker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Budgeted Detection Tests (test-BudgetedDetection.md)
+// ============================================================================
+
+// BD1.1: a zero budget checks one variable per call, high priority first
+func TestBudget_Order(t *testing.T) {
+	tr := NewTracker()
+	low := &Person{Name: "Alice"}
+	high := &Person{Name: "Bob"}
+	r1 := tr.CreateVariable(low, 0, "", map[string]string{"priority": "low"})
+	c1 := tr.CreateVariable(nil, r1.ID, "Name", nil)
+	r2 := tr.CreateVariable(high, 0, "", map[string]string{"priority": "high"})
+	c2 := tr.CreateVariable(nil, r2.ID, "Name", nil)
+	tr.GetChanges()
+	low.Name = "Ann"
+	high.Name = "Ben"
+
+	ctx := context.Background()
+	var found []int64
+	for i := range 4 {
+		changed, complete := tr.DetectChangesBudget(ctx, 0)
+		if complete != (i == 3) {
+			t.Fatalf("call %d: expected complete=%v", i+1, i == 3)
+		}
+		for _, c := range tr.GetChanges() {
+			if !changed {
+				t.Errorf("call %d: change reported without changed", i+1)
+			}
+			found = append(found, c.VariableID)
+		}
+	}
+	if len(found) != 2 || found[0] != c2.ID || found[1] != c1.ID {
+		t.Errorf("expected the high-priority subtree first, got %v", found)
+	}
+}
+
+// BD1.2: passes resume across calls and restart after completing
+func TestBudget_Resume(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	ctx := context.Background()
+	tr.DetectChangesBudget(ctx, 0) // root
+	tr.DetectChangesBudget(ctx, 0) // a
+	tr.DetectChangesBudget(ctx, 0) // aName
+	people[0].Name = "Ann"         // already visited this pass
+	people[1].Name = "Ben"
+	_, complete := tr.DetectChangesBudget(ctx, time.Hour)
+	if ids := changeIDs(tr.GetChanges()); !complete || len(ids) != 1 || !ids[bName.ID] {
+		t.Errorf("expected the rest of the pass to find bName, got %v (complete %v)", ids, complete)
+	}
+	_, complete = tr.DetectChangesBudget(ctx, time.Hour)
+	if ids := changeIDs(tr.GetChanges()); !complete || len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("expected a new pass to find aName, got %v (complete %v)", ids, complete)
+	}
+}
+
+// BD1.3: a done context checks nothing and keeps the marks
+func TestBudget_Context(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.GetChanges()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	people[0].Name = "Ann"
+	tr.MarkVariableDirty(a.ID)
+	changed, complete := NewSyncTracker(tr).DetectChangesBudget(ctx, time.Hour)
+	if changed || complete || len(tr.GetChanges()) != 0 {
+		t.Errorf("expected a done context to check nothing, got %v (complete %v)", changed, complete)
+	}
+	changed, _ = tr.DetectChangesBudget(context.Background(), 0)
+	if ids := changeIDs(tr.GetChanges()); !changed || len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("expected the kept mark to be checked first, got %v", ids)
+	}
+}
+
+// slowPoint takes a second of clock time to read.
+type slowPoint struct {
+	clock *fakeClock
+	x     int
+}
+
+func (s *slowPoint) X() int {
+	s.clock.advance(time.Second)
+	return s.x
+}
+
+// BD1.4: the budget is measured on the tracker's Clock
+func TestBudget_Clock(t *testing.T) {
+	tr := NewTracker()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tr.Clock = clock
+	points := []*slowPoint{{clock: clock}, {clock: clock}, {clock: clock}}
+	root := tr.CreateVariable(points, 0, "", nil)
+	var xs []*Variable
+	for i := range points {
+		xs = append(xs, tr.CreateVariable(nil, root.ID, fmt.Sprintf("%d.X()", i), nil))
+	}
+	tr.GetChanges()
+	for _, p := range points {
+		p.x = 1
+	}
+
+	ctx := context.Background()
+	_, complete := tr.DetectChangesBudget(ctx, 2*time.Second)
+	if ids := changeIDs(tr.GetChanges()); complete || len(ids) != 2 || !ids[xs[0].ID] || !ids[xs[1].ID] {
+		t.Errorf("expected two reads within two seconds, got %v (complete %v)", ids, complete)
+	}
+	_, complete = tr.DetectChangesBudget(ctx, 2*time.Second)
+	if ids := changeIDs(tr.GetChanges()); !complete || len(ids) != 1 || !ids[xs[2].ID] {
+		t.Errorf("expected the last read to complete the pass, got %v (complete %v)", ids, complete)
+	}
+}
@@ -0,0 +1,210 @@
+// CRC: crc-ValueJSONCompare.md
+// Spec: api.md
+package changetracker
+
+import (
+	"encoding"
+	"encoding/json"
+	"math"
+	"reflect"
+)
+
+// jsonEqual compares two Value JSON values for equality.
+// It walks Value JSON shapes directly (primitives, []any, ObjectRef), returns at the
+// first difference, and does not allocate for equal values. Values outside those
+// shapes (structs, types with custom JSON marshaling) fall back to marshalEqual,
+// so results match comparing the serialized JSON.
+// CRC: crc-ValueJSONCompare.md
+func jsonEqual(a, b any) bool {
+	switch av := a.(type) {
+	case nil:
+		return isNullJSON(b)
+	case ObjectRef:
+		bv, ok := b.(ObjectRef)
+		if ok {
+			return av.Obj == bv.Obj
+		}
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			if av == nil {
+				return isNullJSON(b)
+			}
+			break
+		}
+		if av == nil || bv == nil {
+			return (av == nil) == (bv == nil)
+		}
+		if len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case string:
+		if bv, ok := b.(string); ok {
+			return av == bv
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			return av == bv
+		}
+	case int:
+		if bv, ok := b.(int); ok {
+			return av == bv
+		}
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return av == bv
+		}
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return floatEqual(av, bv)
+		}
+	}
+	if b == nil {
+		return isNullJSON(a)
+	}
+	return primitiveEqual(a, b)
+}
+
+// isNullJSON reports whether v serializes to JSON null.
+func isNullJSON(v any) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case []any:
+		return vv == nil
+	case string, bool, int, int64, float64, ObjectRef:
+		return false
+	}
+	if hasCustomJSON(v) {
+		return marshalEqual(v, nil)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// primitiveEqual compares values whose concrete types differ or are named types,
+// using their reflect kinds. Anything that is not a plain string, bool or number
+// is compared with marshalEqual.
+func primitiveEqual(a, b any) bool {
+	if hasCustomJSON(a) || hasCustomJSON(b) {
+		return marshalEqual(a, b)
+	}
+	ra := reflect.ValueOf(a)
+	rb := reflect.ValueOf(b)
+	ka := jsonKind(ra.Kind())
+	kb := jsonKind(rb.Kind())
+	if ka == reflect.Invalid || kb == reflect.Invalid {
+		return marshalEqual(a, b)
+	}
+	if ka != kb && !(isNumberKind(ka) && isNumberKind(kb)) {
+		return false
+	}
+	switch ka {
+	case reflect.String:
+		return ra.String() == rb.String()
+	case reflect.Bool:
+		return ra.Bool() == rb.Bool()
+	}
+	return numberEqual(ra, rb)
+}
+
+// hasCustomJSON reports whether v controls its own JSON encoding.
+func hasCustomJSON(v any) bool {
+	switch v.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
+
+// jsonKind maps a reflect kind to the JSON primitive family it serializes as
+// (String, Bool, Int, Uint, Float64), or Invalid for non-primitives.
+func jsonKind(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.String, reflect.Bool:
+		return k
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return reflect.Invalid
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k == reflect.Int || k == reflect.Uint || k == reflect.Float64
+}
+
+// numberEqual compares two numeric values the way their JSON text would compare.
+func numberEqual(a, b reflect.Value) bool {
+	ka := jsonKind(a.Kind())
+	kb := jsonKind(b.Kind())
+	switch {
+	case ka == reflect.Int && kb == reflect.Int:
+		return a.Int() == b.Int()
+	case ka == reflect.Uint && kb == reflect.Uint:
+		return a.Uint() == b.Uint()
+	case ka == reflect.Int && kb == reflect.Uint:
+		return a.Int() >= 0 && uint64(a.Int()) == b.Uint()
+	case ka == reflect.Uint && kb == reflect.Int:
+		return b.Int() >= 0 && a.Uint() == uint64(b.Int())
+	case ka == reflect.Float64 && kb == reflect.Float64:
+		// float32 values serialize with 32-bit precision
+		if a.Kind() == reflect.Float32 || b.Kind() == reflect.Float32 {
+			return floatEqual(float64(float32(a.Float())), float64(float32(b.Float())))
+		}
+		return floatEqual(a.Float(), b.Float())
+	case ka == reflect.Float64:
+		return floatIntEqual(a, b)
+	default:
+		return floatIntEqual(b, a)
+	}
+}
+
+// floatEqual compares floats as their JSON text would: -0 encodes as "-0", so
+// it differs from 0.
+func floatEqual(a, b float64) bool {
+	return a == b && math.Signbit(a) == math.Signbit(b)
+}
+
+// floatIntEqual compares a float with an integer; they are equal only when the
+// float is integral and represents exactly the same integer. -0 equals no integer.
+func floatIntEqual(f, i reflect.Value) bool {
+	fv := f.Float()
+	if f.Kind() == reflect.Float32 {
+		fv = float64(float32(fv))
+	}
+	if math.Signbit(fv) && fv == 0 {
+		return false
+	}
+	if i.Kind() >= reflect.Uint && i.Kind() <= reflect.Uintptr {
+		u := i.Uint()
+		return fv >= 0 && fv < 1<<64 && float64(u) == fv && uint64(fv) == u
+	}
+	n := i.Int()
+	return fv >= -(1<<63) && fv < 1<<63 && float64(n) == fv && int64(fv) == n
+}
+
+// marshalEqual compares two values by their JSON serialization.
+// This was the original comparison strategy; it is kept as the fallback for
+// values that are not plain Value JSON shapes.
+func marshalEqual(a, b any) bool {
+	aBytes, err1 := json.Marshal(a)
+	bBytes, err2 := json.Marshal(b)
+	if err1 != nil || err2 != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aBytes) == string(bBytes)
+}
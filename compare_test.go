@@ -0,0 +1,155 @@
+package changetracker
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Value JSON Comparator Tests (test-ValueJSONCompare.md)
+// ============================================================================
+
+type testStatus int
+
+type testLabel string
+
+type testPoint struct {
+	X, Y int
+}
+
+func compareCases() []struct {
+	name string
+	a, b any
+} {
+	return []struct {
+		name string
+		a, b any
+	}{
+		{"nil nil", nil, nil},
+		{"nil string", nil, "a"},
+		{"nil empty array", nil, []any{}},
+		{"nil nil array", nil, []any(nil)},
+		{"string equal", "abc", "abc"},
+		{"string differ", "abc", "abd"},
+		{"string vs number", "1", 1},
+		{"bool equal", true, true},
+		{"bool differ", true, false},
+		{"int equal", 42, 42},
+		{"int differ", 42, 43},
+		{"int vs int64", 42, int64(42)},
+		{"int vs uint", 42, uint(42)},
+		{"negative int vs uint", -1, uint64(math.MaxUint64)},
+		{"int vs float", 42, 42.0},
+		{"int vs fractional float", 42, 42.5},
+		{"float32 vs float64", float32(0.1), 0.1},
+		{"float equal", 1.5, 1.5},
+		{"named int", testStatus(3), 3},
+		{"named string", testLabel("x"), "x"},
+		{"named string differ", testLabel("x"), "y"},
+		{"NaN", math.NaN(), math.NaN()},
+		{"negative zero", math.Copysign(0, -1), 0.0},
+		{"negative zero equal", math.Copysign(0, -1), math.Copysign(0, -1)},
+		{"negative zero vs int", math.Copysign(0, -1), 0},
+		{"negative zero float32", float32(math.Copysign(0, -1)), math.Copysign(0, -1)},
+		{"ref equal", ObjectRef{Obj: 1}, ObjectRef{Obj: 1}},
+		{"ref differ", ObjectRef{Obj: 1}, ObjectRef{Obj: 2}},
+		{"ref vs number", ObjectRef{Obj: 1}, 1},
+		{"array equal", []any{1, "a", ObjectRef{Obj: 3}}, []any{1, "a", ObjectRef{Obj: 3}}},
+		{"array differ", []any{1, "a"}, []any{1, "b"}},
+		{"array length", []any{1}, []any{1, 2}},
+		{"array vs nil array", []any{}, []any(nil)},
+		{"array vs string", []any{"a"}, "a"},
+		{"struct equal", testPoint{1, 2}, testPoint{1, 2}},
+		{"struct differ", testPoint{1, 2}, testPoint{2, 1}},
+		{"custom marshaler", time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC()},
+		{"custom marshaler differ", time.Unix(0, 0).UTC(), time.Unix(1, 0).UTC()},
+	}
+}
+
+// VC1.1: jsonEqual agrees with marshal-based comparison
+func TestJSONEqual_MatchesMarshal(t *testing.T) {
+	for _, tc := range compareCases() {
+		want := marshalEqual(tc.a, tc.b)
+		if got := jsonEqual(tc.a, tc.b); got != want {
+			t.Errorf("%s: jsonEqual(%#v, %#v) = %v, marshalEqual = %v", tc.name, tc.a, tc.b, got, want)
+		}
+		if got := jsonEqual(tc.b, tc.a); got != want {
+			t.Errorf("%s (reversed): jsonEqual(%#v, %#v) = %v, marshalEqual = %v", tc.name, tc.b, tc.a, got, want)
+		}
+	}
+}
+
+// VC1.2: equal Value JSON does not allocate
+func TestJSONEqual_NoAllocs(t *testing.T) {
+	var a any = []any{1, "a", true, 2.5, ObjectRef{Obj: 7}, nil}
+	var b any = []any{1, "a", true, 2.5, ObjectRef{Obj: 7}, nil}
+	allocs := testing.AllocsPerRun(100, func() {
+		if !jsonEqual(a, b) {
+			t.Fatal("expected equal")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations, got %v", allocs)
+	}
+}
+
+func benchmarkValues() (any, any) {
+	a := make([]any, 100)
+	b := make([]any, 100)
+	for i := range a {
+		a[i] = ObjectRef{Obj: int64(i)}
+		b[i] = ObjectRef{Obj: int64(i)}
+	}
+	return a, b
+}
+
+func BenchmarkJSONEqual_Array(b *testing.B) {
+	x, y := benchmarkValues()
+	b.ReportAllocs()
+	for b.Loop() {
+		jsonEqual(x, y)
+	}
+}
+
+func BenchmarkMarshalEqual_Array(b *testing.B) {
+	x, y := benchmarkValues()
+	b.ReportAllocs()
+	for b.Loop() {
+		marshalEqual(x, y)
+	}
+}
+
+func BenchmarkJSONEqual_Primitive(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		jsonEqual("hello", "hello")
+	}
+}
+
+func BenchmarkMarshalEqual_Primitive(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		marshalEqual("hello", "hello")
+	}
+}
+
+// BenchmarkDetectChanges measures a detection pass over an unchanged tree.
+func BenchmarkDetectChanges(b *testing.B) {
+	tr := NewTracker()
+	people := make([]*Person, 1000)
+	for i := range people {
+		people[i] = &Person{Name: "p", Age: i}
+	}
+	root := tr.CreateVariable(people, 0, "", nil)
+	for i := range people {
+		item := tr.CreateVariable(nil, root.ID, strconv.Itoa(i), nil)
+		tr.CreateVariable(nil, item.ID, "Name", nil)
+		tr.CreateVariable(nil, item.ID, "Age", nil)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		tr.DetectChanges()
+	}
+}
@@ -0,0 +1,89 @@
+// CRC: crc-ContextResolver.md
+// Spec: api.md, resolver.md
+package changetracker
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// ContextResolver is an optional extension of Resolver for resolvers whose
+// reads can block. When the tracker's Resolver implements it, variable reads
+// use GetContext and CallContext instead of Get and Call, with the context of
+// the DetectChangesContext pass (context.Background() outside one) limited by
+// the variable's timeout property. The default resolver (the Tracker) passes
+// the context to getters whose only parameter is a context.Context. Reads run
+// on the detecting goroutine, so implementations must return once ctx is done
+// for timeouts and cancellation to take effect.
+// CRC: crc-ContextResolver.md
+type ContextResolver interface {
+	// GetContext is Get with a context.
+	GetContext(ctx context.Context, obj any, pathElement any) (any, error)
+
+	// CallContext is Call with a context.
+	CallContext(ctx context.Context, obj any, methodName string) (any, error)
+}
+
+var contextType = reflect.TypeFor[context.Context]()
+
+// context returns the context for reads: the current DetectChangesContext
+// pass's, or context.Background().
+func (t *Tracker) context() context.Context {
+	if t.ctx != nil {
+		return t.ctx
+	}
+	return context.Background()
+}
+
+// cancelled reports whether the current DetectChangesContext pass should stop.
+func (t *Tracker) cancelled() bool {
+	return t.ctx != nil && t.ctx.Err() != nil
+}
+
+// resolveGet reads a path element through the Resolver, with ctx if it is a ContextResolver.
+func (t *Tracker) resolveGet(ctx context.Context, obj any, elem any) (any, error) {
+	if r, ok := t.Resolver.(ContextResolver); ok {
+		return r.GetContext(ctx, obj, elem)
+	}
+	return t.Resolver.Get(obj, elem)
+}
+
+// resolveCall calls a getter through the Resolver, with ctx if it is a
+// ContextResolver or the tracker itself.
+func (t *Tracker) resolveCall(ctx context.Context, obj any, methodName string) (any, error) {
+	if t.Resolver == Resolver(t) {
+		return t.callContext(ctx, obj, methodName)
+	}
+	if r, ok := t.Resolver.(ContextResolver); ok {
+		return r.CallContext(ctx, obj, methodName)
+	}
+	return t.Resolver.Call(obj, methodName)
+}
+
+// parseTimeout parses a timeout property value: a non-negative Go duration
+// ("500ms", "5s"); empty means no timeout.
+func parseTimeout(value string) (time.Duration, error) {
+	return parseDuration("timeout", value, BadTimeoutValue)
+}
+
+// readTimeout navigates from current with a context that expires after the
+// variable's timeout. The read runs on the caller's goroutine, under the
+// caller's lock, so it only ends early if the getters and resolver honor the
+// context: a read that returns after the deadline fails with a Timeout error
+// and its result is discarded. Returns the pass's context error instead if the
+// pass was cancelled.
+// Sequence: seq-get-value.md
+func (v *Variable) readTimeout(current any) (any, []uintptr, error) {
+	pass := v.tracker.context()
+	ctx, cancel := context.WithTimeout(pass, v.timeout)
+	defer cancel()
+	val, via, err := v.navigate(ctx, current)
+	if ctx.Err() == nil {
+		return val, via, err
+	}
+	if err := pass.Err(); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, verror(Timeout, "reading %s took longer than %v", pathString(v.Path), v.timeout)
+}
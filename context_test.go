@@ -0,0 +1,150 @@
+package changetracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Context Resolver Tests (test-ContextResolver.md)
+// ============================================================================
+
+type ctxKey struct{}
+
+// gauge has getters that take a context.
+type gauge struct {
+	Level int
+	seen  any
+}
+
+func (g *gauge) Read(ctx context.Context) int {
+	g.seen = ctx.Value(ctxKey{})
+	return g.Level
+}
+
+// Slow outlasts short timeouts, ignoring its context.
+func (g *gauge) Slow() int {
+	time.Sleep(20 * time.Millisecond)
+	return g.Level
+}
+
+// Wait blocks until its context is done.
+func (g *gauge) Wait(ctx context.Context) int {
+	<-ctx.Done()
+	return -1
+}
+
+// ctxResolver records the context values its reads see.
+type ctxResolver struct {
+	*Tracker
+	seen []any
+}
+
+func (r *ctxResolver) GetContext(ctx context.Context, obj any, pathElement any) (any, error) {
+	r.seen = append(r.seen, ctx.Value(ctxKey{}))
+	return r.Get(obj, pathElement)
+}
+
+func (r *ctxResolver) CallContext(ctx context.Context, obj any, methodName string) (any, error) {
+	r.seen = append(r.seen, ctx.Value(ctxKey{}))
+	return r.Call(obj, methodName)
+}
+
+// CX1.1: the pass context reaches context getters and ContextResolvers
+func TestContext_PassThrough(t *testing.T) {
+	tr := NewTracker()
+	g := &gauge{Level: 1}
+	root := tr.CreateVariable(g, 0, "", nil)
+	read := tr.CreateVariable(nil, root.ID, "Read()", nil)
+	if read.ValueJSON != 1 {
+		t.Errorf("expected a context getter to be readable, got %v", read.ValueJSON)
+	}
+	g.Level = 2
+	ctx := context.WithValue(context.Background(), ctxKey{}, "pass")
+	if changed, err := tr.DetectChangesContext(ctx); !changed || err != nil || g.seen != "pass" {
+		t.Errorf("expected the getter to see the pass context, got %v, %v, %v", changed, err, g.seen)
+	}
+
+	r := &ctxResolver{Tracker: tr}
+	tr.Resolver = r
+	level := tr.CreateVariable(nil, root.ID, "Level", nil)
+	tr.DetectChangesContext(ctx)
+	if len(r.seen) != 3 || r.seen[0] != nil || r.seen[1] != "pass" || r.seen[2] != "pass" {
+		t.Errorf("expected the resolver to see the pass context, got %v", r.seen)
+	}
+	if level.ValueJSON != 2 {
+		t.Errorf("expected the resolver's value, got %v", level.ValueJSON)
+	}
+}
+
+// CX1.2: a done context stops the pass and keeps the marks
+func TestContext_Cancel(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	people[0].Name = "Ann"
+	tr.MarkVariableDirty(aName.ID)
+	changed, err := tr.DetectChangesContext(ctx)
+	if changed || !errors.Is(err, context.Canceled) || len(tr.GetChanges()) != 0 {
+		t.Errorf("expected a cancelled pass to check nothing, got %v, %v", changed, err)
+	}
+	if !tr.DetectDirty() || aName.ValueJSON != "Ann" {
+		t.Errorf("expected the mark to be kept, got %v", aName.ValueJSON)
+	}
+}
+
+// CX1.3: reads past their timeout fail with Timeout and leave the value
+func TestContext_Timeout(t *testing.T) {
+	tr := NewTracker()
+	g := &gauge{Level: 1}
+	root := tr.CreateVariable(g, 0, "", nil)
+	if _, err := tr.TryCreateVariable(nil, root.ID, "Slow()?timeout=never", nil); errorType(err) != BadTimeoutValue {
+		t.Errorf("expected BadTimeoutValue, got %v", err)
+	}
+	slow := tr.CreateVariable(nil, root.ID, "Slow()?timeout=10ms", nil)
+	wait := tr.CreateVariable(nil, root.ID, "Wait()?timeout=10ms", nil)
+	level := tr.CreateVariable(nil, root.ID, "Level?timeout=1m", nil)
+	tr.GetChanges()
+
+	g.Level = 2
+	if !tr.DetectChanges() || level.ValueJSON != 2 {
+		t.Errorf("expected other variables to be read, got %v", level.ValueJSON)
+	}
+	for _, v := range []*Variable{slow, wait} {
+		if errorType(v.Error) != Timeout || v.ValueJSON != nil {
+			t.Errorf("expected Timeout without a value for %v, got %v, %v", v.Path, v.Error, v.ValueJSON)
+		}
+	}
+	if err := slow.TrySetProperty("timeout", "-1s"); errorType(err) != BadTimeoutValue {
+		t.Errorf("expected BadTimeoutValue, got %v", err)
+	}
+}
+
+// CX1.4: budgeted passes pass their context to reads
+func TestContext_Budget(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	tr.CreateVariable(nil, root.ID, "Name", nil)
+	r := &ctxResolver{Tracker: tr}
+	tr.Resolver = r
+	ctx := context.WithValue(context.Background(), ctxKey{}, "budget")
+	for {
+		if _, complete := tr.DetectChangesBudget(ctx, 0); complete {
+			break
+		}
+	}
+	if len(r.seen) != 1 || r.seen[0] != "budget" {
+		t.Errorf("expected the read to get the budget context, got %v", r.seen)
+	}
+}
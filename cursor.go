@@ -0,0 +1,114 @@
+// CRC: crc-Cursor.md
+// Spec: api.md
+package changetracker
+
+import "slices"
+
+// Cursor is an independent change consumer. Each cursor keeps the changes
+// recorded since its last Read, coalesced per variable, so several consumers
+// (e.g. browser tabs) can read the same changes without stealing them from
+// each other or from GetChanges.
+// CRC: crc-Cursor.md
+type Cursor struct {
+	tracker    *Tracker
+	record     *changeRecord
+	pending    int // variables with unread changes
+	max        int // pending limit (0 = unlimited)
+	overflowed bool
+}
+
+// NewCursor registers a cursor that starts with no unread changes.
+// If more than max variables have unread changes, the cursor drops them and
+// reports an overflow on the next Read; max 0 means unlimited.
+// Sequence: seq-cursor.md
+func (t *Tracker) NewCursor(max int) *Cursor {
+	c := &Cursor{tracker: t, record: newChangeRecord(), max: max}
+	t.cursors = append(t.cursors, c)
+	return c
+}
+
+// Read returns the changes since the last Read, sorted by priority, and starts over.
+// If the cursor overflowed, Read returns nil and true: the consumer missed changes
+// and should resynchronize all variables it cares about.
+// Sequence: seq-cursor.md
+func (c *Cursor) Read() (changes []Change, overflowed bool) {
+	if c.overflowed {
+		c.overflowed = false
+		return nil, true
+	}
+	changes = c.tracker.buildChanges(nil, *c.record, c.tracker.ChangeDetails)
+	c.record = newChangeRecord()
+	c.pending = 0
+	return changes, false
+}
+
+// Pending returns the number of variables with unread changes.
+func (c *Cursor) Pending() int {
+	return c.pending
+}
+
+// Overflowed reports whether the cursor dropped changes since its last Read.
+func (c *Cursor) Overflowed() bool {
+	return c.overflowed
+}
+
+// Close unregisters the cursor; it stops retaining changes.
+func (c *Cursor) Close() {
+	c.tracker.cursors = slices.DeleteFunc(c.tracker.cursors, func(other *Cursor) bool { return other == c })
+	c.record = newChangeRecord()
+	c.pending = 0
+}
+
+// recordValue records a value change for the cursor.
+func (c *Cursor) recordValue(v *Variable, keepOld bool) {
+	if c.overflowed {
+		return
+	}
+	c.count(v.ID)
+	c.record.recordValue(v, keepOld)
+	c.checkOverflow()
+}
+
+// recordProperty records a property change for the cursor.
+func (c *Cursor) recordProperty(varID int64, propName, old string, keepOld bool) {
+	if c.overflowed {
+		return
+	}
+	c.count(varID)
+	c.record.recordProperty(varID, propName, old, keepOld)
+	c.checkOverflow()
+}
+
+// recordParent records a parent change for the cursor.
+func (c *Cursor) recordParent(varID int64) {
+	if c.overflowed {
+		return
+	}
+	c.count(varID)
+	c.record.parents[varID] = true
+	c.checkOverflow()
+}
+
+// count counts varID as pending if it has no unread changes yet.
+func (c *Cursor) count(varID int64) {
+	if !c.record.has(varID) {
+		c.pending++
+	}
+}
+
+// checkOverflow drops all unread changes once more than max variables are pending.
+func (c *Cursor) checkOverflow() {
+	if c.max > 0 && c.pending > c.max {
+		c.overflowed = true
+		c.record = newChangeRecord()
+		c.pending = 0
+	}
+}
+
+// forget drops unread changes for a destroyed variable.
+func (c *Cursor) forget(varID int64) {
+	if c.record.has(varID) {
+		c.pending--
+	}
+	c.record.forget(varID)
+}
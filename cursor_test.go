@@ -0,0 +1,137 @@
+package changetracker
+
+import "testing"
+
+// ============================================================================
+// Cursor Tests (test-Cursor.md)
+// ============================================================================
+
+// CU1.1: cursors read independently of each other and of GetChanges
+func TestCursor_Independent(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	c1 := tr.NewCursor(0)
+	c2 := tr.NewCursor(0)
+
+	people[0].Name = "Ann"
+	tr.DetectChanges()
+	tr.GetChanges()
+	if changes, _ := c1.Read(); len(changes) != 1 || changes[0].VariableID != aName.ID {
+		t.Errorf("cursor 1: expected change for %d, got %v", aName.ID, changes)
+	}
+	people[1].Name = "Ben"
+	tr.DetectChanges()
+	if changes, _ := c1.Read(); len(changes) != 1 || changes[0].VariableID != bName.ID {
+		t.Errorf("cursor 1: expected only the new change, got %v", changes)
+	}
+	if changes, _ := c2.Read(); len(changeIDs(changes)) != 2 {
+		t.Errorf("cursor 2: expected both changes, got %v", changes)
+	}
+	if changes, _ := c2.Read(); len(changes) != 0 {
+		t.Errorf("cursor 2: expected nothing after reading, got %v", changes)
+	}
+}
+
+// CU1.2: unread changes coalesce per variable
+func TestCursor_Coalesce(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	tr.ChangeDetails = true
+	c := tr.NewCursor(0)
+	for _, name := range []string{"Ann", "Amy", "Abby"} {
+		people[0].Name = name
+		tr.DetectChanges()
+	}
+	aName.SetProperty("label", "x")
+	aName.SetProperty("label", "y")
+	if c.Pending() != 1 {
+		t.Errorf("expected 1 pending variable, got %d", c.Pending())
+	}
+	changes, _ := c.Read()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 coalesced change, got %v", changes)
+	}
+	ch := changes[0]
+	if !ch.ValueChanged || ch.OldValueJSON != "Alice" || ch.NewValueJSON != "Abby" {
+		t.Errorf("expected Alice -> Abby, got %+v", ch)
+	}
+	if len(ch.PropertiesChanged) != 1 || ch.OldProperties["label"] != "" || ch.NewProperties["label"] != "y" {
+		t.Errorf("expected label '' -> y, got %+v", ch)
+	}
+}
+
+// CU1.3: slow cursors overflow instead of growing without bound
+func TestCursor_Overflow(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	slow := tr.NewCursor(1)
+	fast := tr.NewCursor(1)
+	people[0].Name = "Ann"
+	tr.DetectChanges()
+	fast.Read()
+	people[1].Name = "Ben"
+	tr.DetectChanges()
+	if !slow.Overflowed() || fast.Overflowed() {
+		t.Errorf("expected only the slow cursor to overflow (slow %v, fast %v)", slow.Overflowed(), fast.Overflowed())
+	}
+	if changes, overflowed := slow.Read(); !overflowed || changes != nil {
+		t.Errorf("expected overflow, got %v %v", changes, overflowed)
+	}
+	people[0].Name = "Amy"
+	tr.DetectChanges()
+	if changes, overflowed := slow.Read(); overflowed || len(changes) != 1 {
+		t.Errorf("expected recovery after overflow, got %v %v", changes, overflowed)
+	}
+}
+
+// CU1.4: destroyed variables and closed cursors release changes
+func TestCursor_DestroyAndClose(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	c := tr.NewCursor(0)
+	closed := tr.NewCursor(0)
+	people[0].Name = "Ann"
+	people[1].Name = "Ben"
+	tr.DetectChanges()
+	tr.DestroyVariable(aName.ID)
+	if c.Pending() != 1 {
+		t.Errorf("expected 1 pending after destroy, got %d", c.Pending())
+	}
+	if changes, _ := c.Read(); len(changes) != 1 || changes[0].VariableID != bName.ID {
+		t.Errorf("expected only %d, got %v", bName.ID, changes)
+	}
+	closed.Close()
+	people[1].Name = "Bo"
+	tr.DetectChanges()
+	if len(tr.cursors) != 1 || closed.Pending() != 0 {
+		t.Errorf("expected closed cursor to be released, got %d cursors, %d pending", len(tr.cursors), closed.Pending())
+	}
+}
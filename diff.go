@@ -0,0 +1,200 @@
+// CRC: crc-ArrayDiff.md
+// Spec: api.md
+package changetracker
+
+import "encoding/json"
+
+// ArrayOpType is the kind of an array diff operation.
+// CRC: crc-ArrayDiff.md
+type ArrayOpType string
+
+const (
+	ArrayInsert ArrayOpType = "insert" // insert Value at Index
+	ArrayRemove ArrayOpType = "remove" // remove the element at Index
+	ArrayMove   ArrayOpType = "move"   // remove the element at From, then insert it at Index
+)
+
+// ArrayOp is one step in an array diff.
+// Operations apply in order to the previous array; each index refers to the
+// array as it is after the preceding operations.
+// CRC: crc-ArrayDiff.md
+type ArrayOp struct {
+	Op    ArrayOpType `json:"op"`
+	Index int         `json:"index"`
+	From  int         `json:"from"`  // always encoded, since 0 is a valid source
+	Value any         `json:"value"` // always encoded, since null is a valid element
+}
+
+// ApplyArrayOps applies ops to a copy of old and returns the result.
+// Returns a BadIndex error if an operation is out of range.
+// CRC: crc-ArrayDiff.md
+func ApplyArrayOps(old []any, ops []ArrayOp) ([]any, error) {
+	result := append(make([]any, 0, len(old)), old...)
+	for _, op := range ops {
+		switch op.Op {
+		case ArrayInsert:
+			if op.Index < 0 || op.Index > len(result) {
+				return nil, verror(BadIndex, "insert index %d out of bounds (len=%d)", op.Index, len(result))
+			}
+			result = insertAt(result, op.Index, op.Value)
+		case ArrayRemove:
+			if op.Index < 0 || op.Index >= len(result) {
+				return nil, verror(BadIndex, "remove index %d out of bounds (len=%d)", op.Index, len(result))
+			}
+			result = append(result[:op.Index], result[op.Index+1:]...)
+		case ArrayMove:
+			if op.From < 0 || op.From >= len(result) || op.Index < 0 || op.Index >= len(result) {
+				return nil, verror(BadIndex, "move %d -> %d out of bounds (len=%d)", op.From, op.Index, len(result))
+			}
+			elem := result[op.From]
+			result = append(result[:op.From], result[op.From+1:]...)
+			result = insertAt(result, op.Index, elem)
+		default:
+			return nil, verror(PathError, "unknown array operation %q", op.Op)
+		}
+	}
+	return result, nil
+}
+
+func insertAt(a []any, i int, v any) []any {
+	a = append(a, nil)
+	copy(a[i+1:], a[i:])
+	a[i] = v
+	return a
+}
+
+// diffArrays computes insert, remove and move operations that turn old into cur.
+// Elements that keep their relative order (a longest increasing subsequence of
+// matched old positions) are never moved, which keeps the move count minimal.
+// Elements are matched by their JSON encoding, so nested arrays and objects
+// match too. Returns false if an element cannot be encoded.
+// CRC: crc-ArrayDiff.md
+func diffArrays(old, cur []any) ([]ArrayOp, bool) {
+	// Match each new element with the first unmatched equal element of old
+	positions := make(map[string][]int, len(old))
+	for i, elem := range old {
+		key, ok := elementKey(elem)
+		if !ok {
+			return nil, false
+		}
+		positions[key] = append(positions[key], i)
+	}
+	source := make([]int, len(cur)) // old index for each cur element, or -1 for inserts
+	matched := make([]bool, len(old))
+	for j, elem := range cur {
+		key, ok := elementKey(elem)
+		if !ok {
+			return nil, false
+		}
+		source[j] = -1
+		if queue := positions[key]; len(queue) > 0 {
+			source[j] = queue[0]
+			positions[key] = queue[1:]
+			matched[queue[0]] = true
+		}
+	}
+
+	var ops []ArrayOp
+	// working holds tokens: old index for old elements, -(j+1) for inserted cur[j]
+	working := make([]int, 0, len(old))
+	for i := range old {
+		working = append(working, i)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		if !matched[i] {
+			ops = append(ops, ArrayOp{Op: ArrayRemove, Index: i})
+			working = append(working[:i], working[i+1:]...)
+		}
+	}
+
+	stable := stableSources(source)
+	for j, elem := range cur {
+		token := source[j]
+		if token >= 0 && stable[token] {
+			continue
+		}
+		from := -1
+		if token >= 0 {
+			from = indexOf(working, token)
+			working = append(working[:from], working[from+1:]...)
+		} else {
+			token = -(j + 1)
+		}
+		// Place right after the previous element of cur, which is already in order
+		to := 0
+		if j > 0 {
+			prev := source[j-1]
+			if prev < 0 {
+				prev = -j
+			}
+			to = indexOf(working, prev) + 1
+		}
+		working = append(working, 0)
+		copy(working[to+1:], working[to:])
+		working[to] = token
+		if from >= 0 {
+			ops = append(ops, ArrayOp{Op: ArrayMove, From: from, Index: to})
+		} else {
+			ops = append(ops, ArrayOp{Op: ArrayInsert, Index: to, Value: elem})
+		}
+	}
+	return ops, true
+}
+
+// stableSources returns the old indices in a longest increasing subsequence of
+// source (ignoring inserts); those elements already appear in the right order.
+func stableSources(source []int) map[int]bool {
+	// Patience sorting: tails[k] is the index into source of the smallest tail
+	// of an increasing subsequence of length k+1
+	var tails []int
+	prev := make([]int, len(source))
+	for j, s := range source {
+		if s < 0 {
+			continue
+		}
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if source[tails[mid]] < s {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[j] = -1
+		if lo > 0 {
+			prev[j] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, j)
+		} else {
+			tails[lo] = j
+		}
+	}
+	stable := make(map[int]bool, len(tails))
+	if len(tails) > 0 {
+		for j := tails[len(tails)-1]; j >= 0; j = prev[j] {
+			stable[source[j]] = true
+		}
+	}
+	return stable
+}
+
+func indexOf(a []int, v int) int {
+	for i, x := range a {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// elementKey returns the JSON encoding of an array element, which is the same
+// for equal Value JSON. Maps encode with sorted keys.
+func elementKey(v any) (string, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
@@ -0,0 +1,176 @@
+package changetracker
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// ============================================================================
+// Array Diff Tests (test-ArrayDiff.md)
+// ============================================================================
+
+func refs(ids ...int64) []any {
+	result := make([]any, len(ids))
+	for i, id := range ids {
+		result[i] = ObjectRef{Obj: id}
+	}
+	return result
+}
+
+func countOps(ops []ArrayOp, typ ArrayOpType) int {
+	n := 0
+	for _, op := range ops {
+		if op.Op == typ {
+			n++
+		}
+	}
+	return n
+}
+
+// AD1.1: specific diffs are minimal
+func TestDiffArrays_Cases(t *testing.T) {
+	tests := []struct {
+		name                   string
+		old, cur               []any
+		inserts, removes, move int
+	}{
+		{"append", refs(1, 2, 3), refs(1, 2, 3, 4), 1, 0, 0},
+		{"prepend", refs(1, 2, 3), refs(4, 1, 2, 3), 1, 0, 0},
+		{"remove middle", refs(1, 2, 3), refs(1, 3), 0, 1, 0},
+		{"rotate left", refs(1, 2, 3, 4), refs(2, 3, 4, 1), 0, 0, 1},
+		{"rotate right", refs(1, 2, 3, 4), refs(4, 1, 2, 3), 0, 0, 1},
+		{"swap ends", refs(1, 2, 3, 4), refs(4, 2, 3, 1), 0, 0, 2},
+		{"replace all", refs(1, 2), refs(3, 4), 2, 2, 0},
+		{"from empty", refs(), refs(1, 2), 2, 0, 0},
+		{"to empty", refs(1, 2), refs(), 0, 2, 0},
+		{"duplicates", []any{"a", "b", "a"}, []any{"a", "a", "b"}, 0, 0, 1},
+		{"objects", []any{map[string]any{"x": 1.0}, map[string]any{"x": 2.0, "y": []any{3.0}}},
+			[]any{map[string]any{"y": []any{3.0}, "x": 2.0}, map[string]any{"x": 1.0}, map[string]any{"x": 4.0}}, 1, 0, 1},
+		{"nested arrays", []any{[]any{1.0}, []any{2.0}}, []any{[]any{2.0}, []any{1.0}}, 0, 0, 1},
+	}
+	for _, tc := range tests {
+		ops, ok := diffArrays(tc.old, tc.cur)
+		if !ok {
+			t.Errorf("%s: diff failed", tc.name)
+			continue
+		}
+		if got := countOps(ops, ArrayInsert); got != tc.inserts {
+			t.Errorf("%s: expected %d inserts, got %d (%v)", tc.name, tc.inserts, got, ops)
+		}
+		if got := countOps(ops, ArrayRemove); got != tc.removes {
+			t.Errorf("%s: expected %d removes, got %d (%v)", tc.name, tc.removes, got, ops)
+		}
+		if got := countOps(ops, ArrayMove); got != tc.move {
+			t.Errorf("%s: expected %d moves, got %d (%v)", tc.name, tc.move, got, ops)
+		}
+		result, err := ApplyArrayOps(tc.old, ops)
+		if err != nil || !reflect.DeepEqual(result, tc.cur) {
+			t.Errorf("%s: applying %v gave %v (%v), expected %v", tc.name, ops, result, err, tc.cur)
+		}
+	}
+}
+
+// AD1.2: applying the diff always reproduces the new array
+func TestDiffArrays_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 500 {
+		old := make([]any, rng.Intn(12))
+		for i := range old {
+			old[i] = ObjectRef{Obj: int64(rng.Intn(8))}
+		}
+		cur := make([]any, rng.Intn(12))
+		for i := range cur {
+			cur[i] = ObjectRef{Obj: int64(rng.Intn(8))}
+		}
+		ops, ok := diffArrays(old, cur)
+		if !ok {
+			t.Fatal("diff failed")
+		}
+		result, err := ApplyArrayOps(old, ops)
+		if err != nil || !reflect.DeepEqual(result, cur) {
+			t.Fatalf("old %v cur %v: ops %v gave %v (%v)", old, cur, ops, result, err)
+		}
+	}
+}
+
+// AD1.3: ApplyArrayOps rejects out-of-range operations
+func TestApplyArrayOps_Errors(t *testing.T) {
+	for _, op := range []ArrayOp{
+		{Op: ArrayInsert, Index: 3},
+		{Op: ArrayRemove, Index: 2},
+		{Op: ArrayMove, From: 0, Index: 2},
+		{Op: "swap"},
+	} {
+		if _, err := ApplyArrayOps([]any{1, 2}, []ArrayOp{op}); err == nil {
+			t.Errorf("expected error for %v", op)
+		}
+	}
+}
+
+// AD1.4: zero sources and null values are encoded
+func TestArrayOp_JSON(t *testing.T) {
+	for _, tc := range []struct {
+		op   ArrayOp
+		want string
+	}{
+		{ArrayOp{Op: ArrayMove, From: 0, Index: 2}, `{"op":"move","index":2,"from":0,"value":null}`},
+		{ArrayOp{Op: ArrayInsert, Index: 1}, `{"op":"insert","index":1,"from":0,"value":null}`},
+	} {
+		data, err := json.Marshal(tc.op)
+		if err != nil || string(data) != tc.want {
+			t.Errorf("expected %s, got %s (%v)", tc.want, data, err)
+		}
+	}
+}
+
+// AD2.1: diff=array variables report ArrayOps on their changes
+func TestArrayDiff_Tracker(t *testing.T) {
+	tr := NewTracker()
+	alice, bob, carol := &Person{Name: "Alice"}, &Person{Name: "Bob"}, &Person{Name: "Carol"}
+	type list struct{ People []*Person }
+	data := &list{People: []*Person{alice, bob}}
+	root := tr.CreateVariable(data, 0, "", nil)
+	v := tr.CreateVariable(nil, root.ID, "People?diff=array", nil)
+	old := v.ValueJSON.([]any)
+	tr.GetChanges()
+
+	data.People = []*Person{bob, alice, carol}
+	tr.DetectChanges()
+	changes := tr.GetChanges()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %v", changes)
+	}
+	ops := changes[0].ArrayOps
+	if countOps(ops, ArrayMove) != 1 || countOps(ops, ArrayInsert) != 1 || len(ops) != 2 {
+		t.Errorf("expected one move and one insert, got %v", ops)
+	}
+	result, err := ApplyArrayOps(old, ops)
+	if err != nil || !reflect.DeepEqual(result, v.ValueJSON) {
+		t.Errorf("applying ops gave %v (%v), expected %v", result, err, v.ValueJSON)
+	}
+}
+
+// AD2.2: variables without diff=array and non-array values have no ArrayOps
+func TestArrayDiff_NotRequested(t *testing.T) {
+	tr := NewTracker()
+	type list struct {
+		Tags []string
+		Name string
+	}
+	data := &list{Tags: []string{"a"}, Name: "x"}
+	root := tr.CreateVariable(data, 0, "", nil)
+	tr.CreateVariable(nil, root.ID, "Tags", nil)
+	tr.CreateVariable(nil, root.ID, "Name?diff=array", nil)
+	tr.GetChanges()
+
+	data.Tags = append(data.Tags, "b")
+	data.Name = "y"
+	tr.DetectChanges()
+	for _, c := range tr.GetChanges() {
+		if c.ArrayOps != nil {
+			t.Errorf("variable %d: expected no ArrayOps, got %v", c.VariableID, c.ArrayOps)
+		}
+	}
+}
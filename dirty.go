@@ -0,0 +1,190 @@
+// CRC: crc-DirtyIndex.md
+// Spec: api.md
+package changetracker
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// MarkDirty marks an object as changed. The next DetectDirty checks every
+// variable whose cached Value is obj or whose path last navigated through it,
+// and their descendants. Objects no variable refers to are ignored.
+// Sequence: seq-detect-dirty.md
+func (t *Tracker) MarkDirty(obj any) {
+	if objID, ok := t.LookupObject(obj); ok {
+		t.dirtyObjects[objID] = true
+	}
+	for id := range t.viaVars[objectPointer(obj)] {
+		t.dirtyVariables[id] = true
+	}
+}
+
+// MarkVariableDirty marks a variable as changed. The next DetectDirty checks
+// it and its descendants. Unknown IDs are ignored.
+// Sequence: seq-detect-dirty.md
+func (t *Tracker) MarkVariableDirty(id int64) {
+	if t.variables[id] != nil {
+		t.dirtyVariables[id] = true
+	}
+}
+
+// DetectDirty is DetectChanges restricted to the variables marked since the
+// last DetectDirty or DetectChanges: marked variables, variables whose cached
+// Value is a marked object, and their descendants. Inactive variables and
+// variables under inactive ancestors are skipped. Changes are recorded,
+// subscriptions notified and history grouped exactly as by DetectChanges.
+// Returns true if any value changed.
+// Sequence: seq-detect-dirty.md
+func (t *Tracker) DetectDirty() bool {
+	marked := t.takeMarks()
+	defer t.endPass()
+	if h := t.history; h != nil {
+		h.Begin()
+		defer h.End()
+	}
+	changed := t.checkMarked(marked)
+	t.notify()
+	return changed
+}
+
+// checkMarked checks the marked variables and their descendants, skipping
+// those covered by a marked ancestor or under an inactive one.
+func (t *Tracker) checkMarked(marked map[int64]bool) bool {
+	changed := false
+	for _, id := range slices.Sorted(maps.Keys(marked)) {
+		if !t.dirtyRoot(id, marked) {
+			continue
+		}
+		t.clearErrors(id)
+		changed = t.checkVariable(id) || changed
+	}
+	return changed
+}
+
+// takeMarks clears the marks and returns the marked variables, including the
+// variables holding marked objects and signalled notifiers. The marks stay visible to quiet during
+// the pass, so marks made while checking wait for the next pass.
+func (t *Tracker) takeMarks() map[int64]bool {
+	t.signals.take(t.dirtyObjects)
+	marked := maps.Clone(t.dirtyVariables)
+	for objID := range t.dirtyObjects {
+		for id := range t.objectVars[objID] {
+			marked[id] = true
+		}
+	}
+	t.signalled, t.dirtyObjects = t.dirtyObjects, make(map[int64]bool)
+	t.forced, t.dirtyVariables = t.dirtyVariables, make(map[int64]bool)
+	return marked
+}
+
+// restoreMarks marks again what takeMarks took, for a pass that stopped early.
+func (t *Tracker) restoreMarks() {
+	maps.Copy(t.dirtyObjects, t.signalled)
+	maps.Copy(t.dirtyVariables, t.forced)
+}
+
+// endPass forgets the marks and context of a detection pass.
+func (t *Tracker) endPass() {
+	t.signalled = nil
+	t.forced = nil
+	t.ctx = nil
+}
+
+// dirtyRoot reports whether a marked variable should be checked: it exists,
+// it and its ancestors are active, and no ancestor is marked (the ancestor's
+// check covers it).
+func (t *Tracker) dirtyRoot(id int64, marked map[int64]bool) bool {
+	v := t.variables[id]
+	if v == nil {
+		return false
+	}
+	for p := t.variables[v.ParentID]; p != nil; p = t.variables[p.ParentID] {
+		if !p.Active || marked[p.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// clearErrors resets Error on a variable and its descendants before they are checked.
+func (t *Tracker) clearErrors(id int64) {
+	v := t.variables[id]
+	if v == nil {
+		return
+	}
+	v.Error = nil
+	for _, childID := range v.ChildIDs {
+		t.clearErrors(childID)
+	}
+}
+
+// indexValue files a variable under the registered object its cached Value
+// is, if any, subscribing to the object if it is a notifier. Call it whenever
+// Value changes.
+func (t *Tracker) indexValue(v *Variable) {
+	objID, _ := t.LookupObject(v.Value)
+	if objID == v.objID {
+		return
+	}
+	t.unindexValue(v)
+	if objID != 0 {
+		if t.objectVars[objID] == nil {
+			t.objectVars[objID] = make(map[int64]bool)
+		}
+		t.objectVars[objID][v.ID] = true
+		v.objID = objID
+		t.watchNotifier(v.Value, objID)
+	}
+}
+
+// indexVia files a variable under the objects its path navigated through to
+// reach its value, replacing the previous ones. Pointers are not kept alive, so
+// a collected object's address can be reused; marking the new object then
+// checks the variable needlessly, until its next read refiles it.
+func (t *Tracker) indexVia(v *Variable, via []uintptr) {
+	if slices.Equal(v.via, via) {
+		return
+	}
+	for _, ptr := range v.via {
+		if vars := t.viaVars[ptr]; vars != nil {
+			delete(vars, v.ID)
+			if len(vars) == 0 {
+				delete(t.viaVars, ptr)
+			}
+		}
+	}
+	for _, ptr := range via {
+		if t.viaVars[ptr] == nil {
+			t.viaVars[ptr] = make(map[int64]bool)
+		}
+		t.viaVars[ptr][v.ID] = true
+	}
+	v.via = via
+}
+
+// objectPointer returns the address of a pointer or map value, or 0 for
+// other values and nil.
+func objectPointer(obj any) uintptr {
+	if obj == nil || !canRegisterObj(obj) {
+		return 0
+	}
+	return reflect.ValueOf(obj).Pointer()
+}
+
+// unindexValue removes a variable from the dirty index, unsubscribing from a
+// notifier the last variable held.
+func (t *Tracker) unindexValue(v *Variable) {
+	if v.objID == 0 {
+		return
+	}
+	if vars := t.objectVars[v.objID]; vars != nil {
+		delete(vars, v.ID)
+		if len(vars) == 0 {
+			delete(t.objectVars, v.objID)
+			t.unwatchNotifier(v.objID)
+		}
+	}
+	v.objID = 0
+}
@@ -0,0 +1,181 @@
+package changetracker
+
+import "testing"
+
+// ============================================================================
+// Dirty Marking Tests (test-DirtyIndex.md)
+// ============================================================================
+
+// DI1.1: a marked object re-checks only the variables holding it, with descendants
+func TestDirty_MarkObject(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	var batches [][]Change
+	tr.Subscribe(nil, func(changes []Change) { batches = append(batches, changes) })
+	people[0].Name = "Ann"
+	people[1].Name = "Ben"
+	tr.MarkDirty(people[0])
+	if !tr.DetectDirty() {
+		t.Fatal("expected DetectDirty to find a change")
+	}
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("expected only aName to change, got %v", ids)
+	}
+	if len(batches) != 1 || len(batches[0]) != 1 {
+		t.Errorf("expected one notification with one change, got %v", batches)
+	}
+	// Nothing left marked
+	if tr.DetectDirty() {
+		t.Error("expected marks to be cleared")
+	}
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[bName.ID] {
+		t.Errorf("expected the full scan to find bName, got %v", ids)
+	}
+}
+
+// DI1.2: a marked variable re-checks its subtree; unknown marks are ignored
+func TestDirty_MarkVariable(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	people[0].Name = "Ann"
+	people[1].Name = "Ben"
+	tr.MarkVariableDirty(b.ID)
+	tr.MarkVariableDirty(99)
+	tr.MarkDirty(&Person{})
+	tr.DetectDirty()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[bName.ID] {
+		t.Errorf("expected only bName to change, got %v", ids)
+	}
+	// A marked root covers everything
+	s := NewSyncTracker(tr)
+	s.MarkVariableDirty(root.ID)
+	s.MarkVariableDirty(a.ID)
+	s.DetectDirty()
+	if ids := changeIDs(s.GetChanges()); len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("expected aName from the root's subtree, got %v", ids)
+	}
+}
+
+// DI1.3: the index follows value changes and destruction
+func TestDirty_Index(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	old := people[0]
+	oldID, _ := tr.LookupObject(old)
+	people[0] = &Person{Name: "Carl"}
+	tr.DetectChanges()
+	tr.GetChanges()
+	if tr.objectVars[oldID][a.ID] {
+		t.Error("expected a to leave the old object's entry")
+	}
+
+	old.Name = "Ann"
+	people[0].Name = "Cleo"
+	tr.MarkDirty(old)
+	if tr.DetectDirty() {
+		t.Error("the old object is no longer held by any variable")
+	}
+	tr.MarkDirty(people[0])
+	tr.DetectDirty()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("expected aName to change, got %v", ids)
+	}
+
+	newID, _ := tr.LookupObject(people[0])
+	tr.DestroyVariable(a.ID)
+	if tr.objectVars[newID] != nil {
+		t.Error("expected the destroyed variable to leave the index")
+	}
+}
+
+// DI1.4: inactive variables are skipped; a full scan clears marks
+func TestDirty_Inactive(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	a.SetActive(false)
+	people[0].Name = "Ann"
+	tr.MarkDirty(people[0])
+	tr.MarkVariableDirty(aName.ID)
+	if tr.DetectDirty() {
+		t.Error("expected variables under an inactive parent to be skipped")
+	}
+	a.SetActive(true)
+	tr.MarkVariableDirty(a.ID)
+	tr.DetectChanges()
+	tr.GetChanges()
+	if tr.DetectDirty() {
+		t.Error("expected DetectChanges to clear marks")
+	}
+}
+
+// DI1.5: a marked object checks the variables whose paths navigate through it
+func TestDirty_MarkThrough(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Address: &Address{City: "Paris"}}
+	root := tr.CreateVariable(p, 0, "", nil)
+	city := tr.CreateVariable(nil, root.ID, "Address.City", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	tr.GetChanges()
+
+	p.Address.City = "Lyon"
+	p.Name = "Bob"
+	tr.MarkDirty(p.Address)
+	if !tr.DetectDirty() {
+		t.Fatal("expected DetectDirty to find the change through the marked object")
+	}
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[city.ID] {
+		t.Errorf("expected only city to change, got %v", ids)
+	}
+
+	// The index follows the path to the new object
+	old := p.Address
+	p.Address = &Address{City: "Rome"}
+	tr.DetectChanges()
+	tr.GetChanges()
+	old.City = "Nice"
+	tr.MarkDirty(old)
+	if tr.DetectDirty() {
+		t.Error("the old object is no longer navigated through")
+	}
+	p.Address.City = "Milan"
+	p.Name = "Carol"
+	tr.MarkDirty(p.Address)
+	tr.DetectDirty()
+	if city.ValueJSON != "Milan" || name.ValueJSON != "Bob" {
+		t.Errorf("expected only city to be re-read, got %v, %v", city.ValueJSON, name.ValueJSON)
+	}
+
+	tr.DestroyVariable(city.ID)
+	if len(tr.viaVars) != 0 {
+		t.Errorf("expected the destroyed variable to leave the index, got %v", tr.viaVars)
+	}
+}
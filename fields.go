@@ -0,0 +1,90 @@
+// CRC: crc-StructFields.md
+// Spec: api.md, resolver.md
+package changetracker
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structField describes how a path name maps to a struct field.
+type structField struct {
+	index    []int
+	readonly bool // ct:",readonly": Get only
+}
+
+// structFields maps path names to the fields of one struct type.
+// CRC: crc-StructFields.md
+type structFields struct {
+	byName map[string]*structField
+}
+
+// fieldCache holds *structFields per reflect.Type, shared by all trackers.
+var fieldCache sync.Map
+
+// fieldsOf returns the cached path names for struct type typ.
+// Each visible field is reachable by its ct tag name, falling back to its json tag
+// name, and by its Go name. Tag names win over Go names of other fields.
+// Fields tagged ct:"-" are not reachable.
+func fieldsOf(typ reflect.Type) *structFields {
+	if fields, ok := fieldCache.Load(typ); ok {
+		return fields.(*structFields)
+	}
+	fields := &structFields{byName: make(map[string]*structField)}
+	tagged := make(map[string]bool)
+	for _, f := range reflect.VisibleFields(typ) {
+		name, readonly, ignore := parseFieldTag(f)
+		if ignore {
+			continue
+		}
+		sf := &structField{index: f.Index, readonly: readonly}
+		if name != "" && f.IsExported() {
+			fields.byName[name] = sf
+			tagged[name] = true
+		}
+		if !tagged[f.Name] {
+			fields.byName[f.Name] = sf
+		}
+	}
+	actual, _ := fieldCache.LoadOrStore(typ, fields)
+	return actual.(*structFields)
+}
+
+// parseFieldTag returns the tag name of f (ct, else json), whether it is read-only,
+// and whether the field is ignored.
+func parseFieldTag(f reflect.StructField) (name string, readonly, ignore bool) {
+	if tag, ok := f.Tag.Lookup("ct"); ok {
+		if tag == "-" {
+			return "", false, true
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		for opt := range strings.SplitSeq(opts, ",") {
+			if opt == "readonly" {
+				readonly = true
+			}
+		}
+		if name != "" {
+			return name, readonly, false
+		}
+	}
+	if tag, ok := f.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "-" {
+			return name, readonly, false
+		}
+	}
+	return "", readonly, false
+}
+
+// field returns the field of struct value rv for a path name.
+func (fields *structFields) field(rv reflect.Value, name string) (reflect.Value, *structField, error) {
+	sf := fields.byName[name]
+	if sf == nil {
+		return reflect.Value{}, nil, verror(PathError, "field %q not found", name)
+	}
+	field, err := rv.FieldByIndexErr(sf.index)
+	if err != nil {
+		return reflect.Value{}, nil, verror(NilPath, "field %q is inside a nil embedded pointer", name)
+	}
+	return field, sf, nil
+}
@@ -0,0 +1,117 @@
+package changetracker
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ============================================================================
+// Struct Tag Tests (test-StructFields.md)
+// ============================================================================
+
+type TaggedBase struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type Tagged struct {
+	TaggedBase
+	FirstName string `ct:"firstName"`
+	LastName  string `json:"lastName,omitempty"`
+	Nickname  string `ct:"nick" json:"nickname"`
+	Secret    string `ct:"-"`
+	ID        int    `ct:"id,readonly"`
+	Version   int    `ct:",readonly"`
+	Plain     string `json:"-"`
+	Other     string `ct:"Plain"`
+	hidden    string `ct:"hiddenName"`
+}
+
+// SF1.1: Get honors ct tags, json fallback, Go names, and ct:"-"
+func TestStructTags_Get(t *testing.T) {
+	tr := NewTracker()
+	obj := &Tagged{
+		TaggedBase: TaggedBase{CreatedBy: "root"},
+		FirstName:  "Ada", LastName: "Lovelace", Nickname: "Countess",
+		Secret: "s", ID: 7, Version: 2, Plain: "p", Other: "o", hidden: "h",
+	}
+	tests := []struct {
+		name string
+		want any
+	}{
+		{"firstName", "Ada"},
+		{"FirstName", "Ada"},
+		{"lastName", "Lovelace"},
+		{"nick", "Countess"},
+		{"id", 7},
+		{"Version", 2},
+		{"createdBy", "root"},
+		{"CreatedBy", "root"},
+		{"Plain", "o"}, // tag names win over Go names
+	}
+	for _, tc := range tests {
+		got, err := tr.Get(obj, tc.name)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.name, tc.want, got, err)
+		}
+	}
+	for _, name := range []string{"Secret", "nickname", "hiddenName", "hidden", "missing"} {
+		if _, err := tr.Get(obj, name); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// SF1.2: Set honors tags and rejects read-only fields
+func TestStructTags_Set(t *testing.T) {
+	tr := NewTracker()
+	obj := &Tagged{}
+	if err := tr.Set(obj, "firstName", "Grace"); err != nil || obj.FirstName != "Grace" {
+		t.Errorf("expected FirstName Grace, got %q (%v)", obj.FirstName, err)
+	}
+	if err := tr.Set(obj, "createdBy", "admin"); err != nil || obj.CreatedBy != "admin" {
+		t.Errorf("expected CreatedBy admin, got %q (%v)", obj.CreatedBy, err)
+	}
+	for _, name := range []string{"id", "Version"} {
+		if err := tr.Set(obj, name, 9); errorType(err) != BadAccess {
+			t.Errorf("%s: expected BadAccess, got %v", name, err)
+		}
+	}
+	if err := tr.Set(obj, "Secret", "x"); err == nil || obj.Secret != "" {
+		t.Errorf("expected error setting ignored field, got %v", err)
+	}
+}
+
+// SF1.3: variables navigate tagged paths
+func TestStructTags_Variables(t *testing.T) {
+	tr := NewTracker()
+	obj := &Tagged{FirstName: "Ada", ID: 1}
+	root := tr.CreateVariable(obj, 0, "", nil)
+	first := tr.CreateVariable(nil, root.ID, "firstName", nil)
+	id := tr.CreateVariable(nil, root.ID, "id", nil)
+	tr.GetChanges()
+
+	obj.FirstName = "Grace"
+	tr.DetectChanges()
+	if changes := tr.GetChanges(); len(changes) != 1 || changes[0].VariableID != first.ID {
+		t.Errorf("expected change for firstName, got %v", changes)
+	}
+	if err := id.Set(2); errorType(err) != BadAccess || obj.ID != 1 {
+		t.Errorf("expected BadAccess for read-only id, got %v", err)
+	}
+}
+
+// SF1.4: tag metadata is computed once per type
+func TestStructTags_Cached(t *testing.T) {
+	typ := reflect.TypeFor[Tagged]()
+	if fieldsOf(typ) != fieldsOf(typ) {
+		t.Error("expected cached field metadata")
+	}
+}
+
+func BenchmarkGetByString_Tagged(b *testing.B) {
+	tr := NewTracker()
+	obj := &Tagged{FirstName: "Ada"}
+	for b.Loop() {
+		tr.Get(obj, "firstName")
+	}
+}
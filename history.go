@@ -0,0 +1,220 @@
+// CRC: crc-History.md
+// Spec: api.md
+package changetracker
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// History records value changes of read-write variables as undo steps.
+// Each Variable.Set is a step, as are all changes found by one DetectChanges
+// and all sets of one Transaction; Begin and End group steps further.
+// CRC: crc-History.md
+type History struct {
+	tracker  *Tracker
+	depth    int // maximum undo steps (0 = unlimited)
+	undo     []historyStep
+	redo     []historyStep
+	group    int          // nesting level of open groups
+	step     *historyStep // step being recorded while a group is open
+	applying bool         // Undo, Redo or a move is setting values
+}
+
+// historyStep is one undo step, in the order the changes happened.
+type historyStep []historyEntry
+
+// historyEntry is one variable's value before and after a step, as Value JSON.
+type historyEntry struct {
+	varID    int64
+	old, new any
+}
+
+// NewHistory starts recording undo history for the tracker, keeping at most
+// depth steps (0 = unlimited). It replaces any previous history.
+// Sequence: seq-history.md
+func (t *Tracker) NewHistory(depth int) *History {
+	h := &History{tracker: t, depth: depth}
+	t.history = h
+	return h
+}
+
+// Close stops recording history.
+func (h *History) Close() {
+	if h.tracker.history == h {
+		h.tracker.history = nil
+	}
+}
+
+// Begin opens a group: changes until the matching End form one undo step.
+// Groups nest; only the outermost End closes the step.
+func (h *History) Begin() {
+	if h.group == 0 {
+		h.step = &historyStep{}
+	}
+	h.group++
+}
+
+// End closes a group opened by Begin.
+func (h *History) End() {
+	if h.group == 0 {
+		return
+	}
+	h.group--
+	if h.group == 0 {
+		h.push(*h.step)
+		h.step = nil
+	}
+}
+
+// CanUndo reports whether there is a step to undo.
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo reports whether there is an undone step to redo.
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Undo restores the values from before the most recent step, in reverse
+// order, and makes the step available to Redo. Variables that no longer exist
+// or cannot be set are skipped and reported in the returned error; if none of
+// the step's values could be restored, the step stays where it is.
+// Sequence: seq-history.md
+func (h *History) Undo() error {
+	if !h.CanUndo() {
+		return nil
+	}
+	step := h.undo[len(h.undo)-1]
+	var errs []error
+	for i := len(step) - 1; i >= 0; i-- {
+		errs = append(errs, h.apply(step[i].varID, step[i].old))
+	}
+	if !failed(errs) {
+		h.undo = h.undo[:len(h.undo)-1]
+		h.redo = append(h.redo, step)
+	}
+	return errors.Join(errs...)
+}
+
+// Redo reapplies the most recently undone step, as Undo does.
+// Sequence: seq-history.md
+func (h *History) Redo() error {
+	if !h.CanRedo() {
+		return nil
+	}
+	step := h.redo[len(h.redo)-1]
+	var errs []error
+	for _, e := range step {
+		errs = append(errs, h.apply(e.varID, e.new))
+	}
+	if !failed(errs) {
+		h.redo = h.redo[:len(h.redo)-1]
+		h.undo = append(h.undo, step)
+	}
+	return errors.Join(errs...)
+}
+
+// failed reports whether every entry of a step failed to apply.
+func failed(errs []error) bool {
+	return !slices.Contains(errs, nil)
+}
+
+// apply sets a variable to a recorded Value JSON without recording history.
+// Like a transaction, it writes the value and then refreshes the variable, so
+// the change is recorded for GetChanges, subscriptions and cursors only once
+// the write succeeded.
+func (h *History) apply(varID int64, valueJSON any) error {
+	v := h.tracker.variables[varID]
+	if v == nil {
+		return verror(NotFound, "variable %d not found", varID)
+	}
+	if !v.IsWritable() {
+		return v.verror(BadAccess, "cannot restore read-only variable (access: %q)", v.GetAccess())
+	}
+	current, _ := v.GetValue()
+	value, err := h.tracker.fromValueJSON(valueJSON, reflect.TypeOf(current))
+	if err != nil {
+		return err
+	}
+	cached := v.Value
+	if err := v.setValue(value); err != nil {
+		v.Value = cached
+		h.tracker.indexValue(v)
+		return err
+	}
+	h.applying = true
+	defer func() { h.applying = false }()
+	h.tracker.refresh(v)
+	return nil
+}
+
+// record adds a change to the current step, or makes it a step of its own
+// outside groups. Only read-write variables are recorded.
+func (h *History) record(v *Variable, old any) {
+	if h.applying || v.GetAccess() != "rw" {
+		return
+	}
+	if h.group == 0 {
+		h.push(historyStep{{v.ID, old, v.ValueJSON}})
+		return
+	}
+	for i := range *h.step {
+		if e := &(*h.step)[i]; e.varID == v.ID {
+			e.new = v.ValueJSON
+			return
+		}
+	}
+	*h.step = append(*h.step, historyEntry{v.ID, old, v.ValueJSON})
+}
+
+// push adds a step to the undo stack, clearing redo and enforcing the depth.
+func (h *History) push(step historyStep) {
+	if len(step) == 0 {
+		return
+	}
+	h.undo = append(h.undo, step)
+	h.redo = nil
+	if h.depth > 0 && len(h.undo) > h.depth {
+		h.undo = h.undo[len(h.undo)-h.depth:]
+	}
+}
+
+// fromValueJSON converts Value JSON back to a value: object references become
+// their objects, and arrays become slices of typ when typ is a slice type.
+func (t *Tracker) fromValueJSON(valueJSON any, typ reflect.Type) (any, error) {
+	switch val := valueJSON.(type) {
+	case ObjectRef:
+		obj := t.GetObject(val.Obj)
+		if obj == nil {
+			return nil, verror(BadReference, "object %d no longer exists", val.Obj)
+		}
+		return obj, nil
+	case []any:
+		if val == nil {
+			return nil, nil
+		}
+		if typ == nil || typ.Kind() != reflect.Slice {
+			typ = reflect.TypeFor[[]any]()
+		}
+		result := reflect.MakeSlice(typ, len(val), len(val))
+		for i, elemJSON := range val {
+			elem, err := t.fromValueJSON(elemJSON, nil)
+			if err != nil {
+				return nil, err
+			}
+			rv := reflect.ValueOf(elem)
+			if !rv.IsValid() {
+				continue // leave the zero value
+			}
+			if !rv.Type().AssignableTo(typ.Elem()) {
+				return nil, verror(PathError, "type mismatch: cannot restore %s into %s", rv.Type(), typ)
+			}
+			result.Index(i).Set(rv)
+		}
+		return result.Interface(), nil
+	}
+	return valueJSON, nil
+}
@@ -0,0 +1,214 @@
+package changetracker
+
+import (
+	"slices"
+	"testing"
+)
+
+// ============================================================================
+// History Tests (test-History.md)
+// ============================================================================
+
+// HI1.1: each Set is an undo step; Undo and Redo go through Set
+func TestHistory_UndoRedo(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	h := tr.NewHistory(0)
+	name.Set("Bob")
+	name.Set("Carol")
+	if err := h.Undo(); err != nil || p.Name != "Bob" || name.ValueJSON != "Bob" {
+		t.Errorf("expected Bob after undo, got %q (%v)", p.Name, err)
+	}
+	h.Undo()
+	if p.Name != "Alice" || h.CanUndo() {
+		t.Errorf("expected Alice and nothing left to undo, got %q", p.Name)
+	}
+	h.Redo()
+	if p.Name != "Bob" || !h.CanRedo() {
+		t.Errorf("expected Bob after redo, got %q", p.Name)
+	}
+	age.Set(40)
+	if h.CanRedo() {
+		t.Error("expected a new step to clear redo")
+	}
+	tr.DetectChanges()
+	if h.CanRedo() || len(h.undo) != 2 {
+		t.Errorf("expected undo and redo not to be recorded as steps, got %d steps", len(h.undo))
+	}
+}
+
+// HI1.2: detected changes form one step; only read-write variables are recorded
+func TestHistory_Detected(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	tr.CreateVariable(nil, root.ID, "Name", nil)
+	tr.CreateVariable(nil, root.ID, "Age", nil)
+	h := tr.NewHistory(0)
+	counter := &Counter{value: 1}
+	croot := tr.CreateVariable(counter, 0, "", nil)
+	tr.CreateVariable(nil, croot.ID, "Value()?access=r", nil)
+	tr.CreateVariable(nil, croot.ID, "Count()?access=action", nil)
+	p.Name = "Bob"
+	p.Age = 31
+	counter.value = 2
+	tr.DetectChanges()
+	if len(h.undo) != 1 || len(h.undo[0]) != 2 {
+		t.Fatalf("expected one step with 2 entries, got %v", h.undo)
+	}
+	h.Undo()
+	if p.Name != "Alice" || p.Age != 30 || counter.value != 2 {
+		t.Errorf("expected name and age restored only, got %+v, %d", p, counter.value)
+	}
+}
+
+// HI1.3: groups nest, and a transaction is one step
+func TestHistory_Groups(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	h := tr.NewHistory(0)
+	h.Begin()
+	name.Set("Bob")
+	h.Begin()
+	name.Set("Carol")
+	age.Set(31)
+	h.End()
+	if h.CanUndo() {
+		t.Error("expected inner End not to close the step")
+	}
+	h.End()
+	tr.Transaction(func(tx *Tx) error {
+		tx.Set(name.ID, "Dan")
+		return tx.Set(age.ID, 32)
+	})
+	if len(h.undo) != 2 {
+		t.Fatalf("expected 2 steps, got %v", h.undo)
+	}
+	h.Undo()
+	if p.Name != "Carol" || p.Age != 31 {
+		t.Errorf("expected transaction undone, got %+v", p)
+	}
+	h.Undo()
+	if p.Name != "Alice" || p.Age != 30 {
+		t.Errorf("expected group undone to the first values, got %+v", p)
+	}
+}
+
+// HI1.4: depth limits drop the oldest steps
+func TestHistory_Depth(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	h := tr.NewHistory(2)
+	for _, n := range []string{"B", "C", "D"} {
+		name.Set(n)
+	}
+	for h.CanUndo() {
+		h.Undo()
+	}
+	if p.Name != "B" {
+		t.Errorf("expected the oldest step to be dropped, got %q", p.Name)
+	}
+}
+
+// HI1.5: object references and arrays are restored as values
+func TestHistory_Values(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Address: &Address{City: "Paris"}, Tags: []string{"a"}}
+	root := tr.CreateVariable(p, 0, "", nil)
+	address := tr.CreateVariable(nil, root.ID, "Address", nil)
+	tags := tr.CreateVariable(nil, root.ID, "Tags", nil)
+	h := tr.NewHistory(0)
+	oldAddr := p.Address
+	address.Set(&Address{City: "Rome"})
+	tags.Set([]string{"b", "c"})
+	h.Undo()
+	h.Undo()
+	if p.Address != oldAddr || !slices.Equal(p.Tags, []string{"a"}) {
+		t.Errorf("expected old address and tags, got %+v %v", p.Address, p.Tags)
+	}
+}
+
+// HI1.6: destroyed variables are skipped and reported
+func TestHistory_Destroyed(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	h := tr.NewHistory(0)
+	h.Begin()
+	name.Set("Bob")
+	age.Set(31)
+	h.End()
+	tr.DestroyVariable(name.ID)
+	if err := h.Undo(); errorType(err) != NotFound || p.Age != 30 {
+		t.Errorf("expected NotFound and age restored, got %v (%d)", err, p.Age)
+	}
+	if !h.CanRedo() {
+		t.Error("expected a partly restored step to move to redo")
+	}
+	h.Close()
+	age.Set(40)
+	if h.CanUndo() {
+		t.Error("expected closed history to stop recording")
+	}
+}
+
+// HI1.7: undone and redone values are reported as changes
+func TestHistory_Changes(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	h := tr.NewHistory(0)
+	name.Set("Bob")
+	tr.GetChanges()
+
+	h.Undo()
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[name.ID] || p.Name != "Alice" {
+		t.Errorf("expected the undone value as a change, got %v (%q)", ids, p.Name)
+	}
+	h.Redo()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[name.ID] || p.Name != "Bob" {
+		t.Errorf("expected the redone value as a change, got %v (%q)", ids, p.Name)
+	}
+	h.Redo() // nothing to redo
+	if changes := tr.GetChanges(); len(changes) != 0 {
+		t.Errorf("expected no changes without a step, got %v", changes)
+	}
+}
+
+// HI1.8: a failed Undo records nothing and keeps the step
+func TestHistory_Failed(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	h := tr.NewHistory(0)
+	name.Set("Bob")
+	name.SetProperty("access", "r")
+	tr.GetChanges()
+
+	if err := h.Undo(); errorType(err) != BadAccess || p.Name != "Bob" {
+		t.Errorf("expected BadAccess and the value kept, got %v (%q)", err, p.Name)
+	}
+	if changes := tr.GetChanges(); len(changes) != 0 {
+		t.Errorf("expected no changes from a failed undo, got %v", changes)
+	}
+	if !h.CanUndo() || h.CanRedo() {
+		t.Error("expected the step to stay on the undo stack")
+	}
+	name.SetProperty("access", "rw")
+	if err := h.Undo(); err != nil || p.Name != "Alice" || !h.CanRedo() {
+		t.Errorf("expected the undo to succeed once writable, got %v (%q)", err, p.Name)
+	}
+}
@@ -0,0 +1,136 @@
+// CRC: crc-IDAllocator.md
+// Spec: api.md
+package changetracker
+
+import (
+	"fmt"
+	"math"
+)
+
+// IDAllocator vends candidate IDs for variables or registered objects.
+// The tracker skips candidates that are already in use, so allocators only
+// need to produce distinct IDs. NextID returns 0 when the allocator is exhausted.
+// CRC: crc-IDAllocator.md
+type IDAllocator interface {
+	NextID() int64
+}
+
+// PositiveIDs allocates increasing positive IDs, up to math.MaxInt64.
+// CRC: crc-IDAllocator.md
+type PositiveIDs struct {
+	next int64
+}
+
+// NewPositiveIDs creates an allocator that starts at start (1 if start < 1).
+func NewPositiveIDs(start int64) *PositiveIDs {
+	return &PositiveIDs{next: max(start, 1)}
+}
+
+func (a *PositiveIDs) NextID() int64 {
+	id := a.next
+	if id == math.MaxInt64 {
+		a.next = 0 // never wrap into the negative IDs
+	} else if id != 0 {
+		a.next++
+	}
+	return id
+}
+
+// NegativeIDs allocates decreasing negative IDs: -1, -2, ... down to math.MinInt64.
+// Servers use these so they never collide with client-chosen positive IDs.
+// CRC: crc-IDAllocator.md
+type NegativeIDs struct {
+	next int64
+}
+
+// NewNegativeIDs creates an allocator that starts at start (-1 if start > -1).
+func NewNegativeIDs(start int64) *NegativeIDs {
+	return &NegativeIDs{next: min(start, -1)}
+}
+
+func (a *NegativeIDs) NextID() int64 {
+	id := a.next
+	if id == math.MinInt64 {
+		a.next = 0 // never wrap into the positive IDs
+	} else if id != 0 {
+		a.next--
+	}
+	return id
+}
+
+// NamespacedIDs allocates IDs of the form namespace<<bits + n for n = 1, 2, ...
+// so several allocators (e.g. one per client) can share a tracker without colliding.
+// CRC: crc-IDAllocator.md
+type NamespacedIDs struct {
+	base  int64
+	next  int64
+	limit int64
+}
+
+// NewNamespacedIDs creates an allocator for namespace (>= 0) that uses the low
+// bits bits of each ID for the counter. It panics if bits is not between 1
+// and 62 or the namespace's IDs would not fit in a positive int64, since they
+// would then overlap another namespace's range.
+func NewNamespacedIDs(namespace int64, bits uint) *NamespacedIDs {
+	if bits < 1 || bits > 62 {
+		panic(fmt.Sprintf("NewNamespacedIDs: bits must be between 1 and 62, got %d", bits))
+	}
+	if namespace < 0 || namespace >= 1<<(63-bits) {
+		panic(fmt.Sprintf("NewNamespacedIDs: namespace %d out of range for %d bits (0 to %d)", namespace, bits, int64(1)<<(63-bits)-1))
+	}
+	return &NamespacedIDs{base: namespace << bits, next: 1, limit: 1 << bits}
+}
+
+func (a *NamespacedIDs) NextID() int64 {
+	if a.next >= a.limit {
+		return 0
+	}
+	id := a.base + a.next
+	a.next++
+	return id
+}
+
+// sharedIDs is the default allocator: the tracker's single counter, shared by
+// variables and objects.
+type sharedIDs struct {
+	tracker *Tracker
+}
+
+func (a sharedIDs) NextID() int64 {
+	id := a.tracker.nextID
+	a.tracker.nextID++
+	return id
+}
+
+// allocateID returns the next unused ID from alloc (or the shared counter if nil).
+// A candidate of 0 means the allocator is exhausted.
+// Returns an IDConflict error if the allocator is exhausted or keeps returning IDs in use.
+func (t *Tracker) allocateID(alloc IDAllocator) (int64, error) {
+	if alloc == nil {
+		alloc = sharedIDs{t}
+	}
+	// Distinct candidates can only hit each used or reserved ID (and the one being created) once
+	for range len(t.variables) + len(t.idToPtr) + len(t.reserved) + 2 {
+		id := alloc.NextID()
+		if id == 0 {
+			return 0, verror(IDConflict, "ID allocator exhausted")
+		}
+		if !t.idInUse(id) {
+			return id, nil
+		}
+	}
+	return 0, verror(IDConflict, "ID allocator returned only IDs that are in use")
+}
+
+// idInUse reports whether id belongs to a variable (including one being created
+// or reserved by Restore) or a registered object.
+func (t *Tracker) idInUse(id int64) bool {
+	if id == t.creating || t.reserved[id] {
+		return true
+	}
+	if _, ok := t.variables[id]; ok {
+		return true
+	}
+	_, ok := t.idToPtr[id]
+	return ok
+}
@@ -0,0 +1,129 @@
+package changetracker
+
+import (
+	"math"
+	"testing"
+)
+
+// ============================================================================
+// ID Allocator Tests (test-IDAllocator.md)
+// ============================================================================
+
+// ID1.1: built-in allocators produce their sequences
+func TestIDAllocators(t *testing.T) {
+	tests := []struct {
+		name  string
+		alloc IDAllocator
+		want  []int64
+	}{
+		{"positive", NewPositiveIDs(5), []int64{5, 6, 7}},
+		{"positive clamps", NewPositiveIDs(-3), []int64{1, 2, 3}},
+		{"negative", NewNegativeIDs(0), []int64{-1, -2, -3}},
+		{"namespaced", NewNamespacedIDs(3, 4), []int64{49, 50, 51}},
+		{"namespaced exhausts", NewNamespacedIDs(1, 2), []int64{5, 6, 7, 0, 0}},
+		{"namespaced last", NewNamespacedIDs(1, 62), []int64{1<<62 + 1, 1<<62 + 2}},
+		{"positive exhausts", NewPositiveIDs(math.MaxInt64 - 1), []int64{math.MaxInt64 - 1, math.MaxInt64, 0, 0}},
+		{"negative exhausts", NewNegativeIDs(math.MinInt64 + 1), []int64{math.MinInt64 + 1, math.MinInt64, 0, 0}},
+	}
+	for _, tc := range tests {
+		for i, want := range tc.want {
+			if got := tc.alloc.NextID(); got != want {
+				t.Errorf("%s: ID %d: expected %d, got %d", tc.name, i, want, got)
+			}
+		}
+	}
+}
+
+// ID1.2: variables and objects draw from separate allocators
+func TestIDAllocators_Separate(t *testing.T) {
+	tr := NewTracker()
+	tr.VariableIDs = NewPositiveIDs(1)
+	tr.ObjectIDs = NewNegativeIDs(-1)
+	alice := &Person{Name: "Alice"}
+	v1 := tr.CreateVariable(alice, 0, "", nil)
+	v2 := tr.CreateVariable(&Person{Name: "Bob"}, 0, "", nil)
+	if v1.ID != 1 || v2.ID != 2 {
+		t.Errorf("expected variable IDs 1 and 2, got %d and %d", v1.ID, v2.ID)
+	}
+	if id, ok := tr.LookupObject(alice); !ok || id != -1 {
+		t.Errorf("expected object ID -1, got %d (%v)", id, ok)
+	}
+}
+
+// ID1.3: allocators skip IDs used by variables or objects
+func TestIDAllocators_SkipInUse(t *testing.T) {
+	tr := NewTracker()
+	tr.VariableIDs = NewPositiveIDs(1)
+	tr.ObjectIDs = NewPositiveIDs(1)
+	tr.CreateVariableWithId(2, 1, 0, "", nil)
+	v := tr.CreateVariable(&Person{}, 0, "", nil) // variable 1, object 1 is taken next
+	if v.ID != 1 {
+		t.Errorf("expected variable ID 1, got %d", v.ID)
+	}
+	if id, _ := tr.LookupObject(v.Value); id != 3 {
+		t.Errorf("expected object ID 3 (skipping variables 1 and 2), got %d", id)
+	}
+	if v := tr.CreateVariable(4, 0, "", nil); v.ID != 4 {
+		t.Errorf("expected variable ID 4 (skipping 2 and object 3), got %d", v.ID)
+	}
+}
+
+// ID1.4: explicit IDs that collide with variables or objects are IDConflict errors
+func TestTryCreateVariableWithId_Conflict(t *testing.T) {
+	tr := NewTracker()
+	v := tr.CreateVariable(&Person{}, 0, "", nil) // variable 1, object 2
+	for _, id := range []int64{0, v.ID, 2} {
+		if _, err := tr.TryCreateVariableWithId(id, 1, 0, "", nil); errorType(err) != IDConflict {
+			t.Errorf("ID %d: expected IDConflict, got %v", id, err)
+		}
+	}
+	if v := tr.CreateVariableWithId(2, 1, 0, "", nil); v != nil {
+		t.Errorf("expected nil for ID used by an object, got %v", v)
+	}
+	if v, err := tr.TryCreateVariableWithId(3, 1, 0, "", nil); err != nil || v.ID != 3 {
+		t.Errorf("expected variable 3, got %v (%v)", v, err)
+	}
+}
+
+// ID1.5: exhausted allocators are reported instead of reusing IDs
+func TestIDAllocators_Exhausted(t *testing.T) {
+	tr := NewTracker()
+	tr.VariableIDs = NewNamespacedIDs(0, 1) // only ID 1
+	tr.CreateVariable(1, 0, "", nil)
+	if _, err := tr.allocateID(tr.VariableIDs); errorType(err) != IDConflict {
+		t.Errorf("expected IDConflict, got %v", err)
+	}
+	if v := tr.CreateVariable(2, 0, "", nil); v != nil {
+		t.Errorf("expected nil from exhausted allocator, got variable %d", v.ID)
+	}
+	tr.VariableIDs = NewNamespacedIDs(0, 2) // 1, 2, 3, but 1 is taken
+	tr.CreateVariableWithId(2, 1, 0, "", nil)
+	tr.CreateVariableWithId(3, 1, 0, "", nil)
+	if _, err := tr.allocateID(tr.VariableIDs); errorType(err) != IDConflict {
+		t.Errorf("expected IDConflict when all IDs are in use, got %v", err)
+	}
+}
+
+// ID1.6: namespaces whose IDs would overlap another range are rejected
+func TestNamespacedIDs_OutOfRange(t *testing.T) {
+	tests := []struct {
+		namespace int64
+		bits      uint
+	}{
+		{-1, 8},
+		{1 << 55, 8}, // namespace<<8 overflows into the negative IDs
+		{2, 62},
+		{0, 0},
+		{0, 63},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewNamespacedIDs(%d, %d): expected a panic", tt.namespace, tt.bits)
+				}
+			}()
+			NewNamespacedIDs(tt.namespace, tt.bits)
+		}()
+	}
+}
@@ -0,0 +1,115 @@
+// CRC: crc-ChangeNotifier.md
+// Spec: api.md
+package changetracker
+
+import (
+	"maps"
+	"sync"
+)
+
+// ChangeNotifier is implemented by domain objects that know when they change.
+// When a variable first holds such an object, the tracker calls OnChange once
+// with a callback that marks the object dirty, and calls the returned cancel
+// function when no variable holds it any more. Change detection skips the
+// children of a variable holding the object until the callback is called.
+// The callback may be called from any goroutine.
+// CRC: crc-ChangeNotifier.md
+type ChangeNotifier interface {
+	OnChange(fn func()) (cancel func())
+}
+
+// notifierSignals collects ChangeNotifier callbacks until the next detection
+// pass takes them. Notifiers may call back from their own goroutines, so it
+// has its own lock and is the only tracker state the callbacks touch.
+type notifierSignals struct {
+	mu      sync.Mutex
+	watched map[int64]bool // registered notifiers
+	pending map[int64]bool // notifiers that called back since the last take
+}
+
+func newNotifierSignals() *notifierSignals {
+	return &notifierSignals{watched: make(map[int64]bool), pending: make(map[int64]bool)}
+}
+
+// signal records a callback from a notifier that is still registered.
+func (s *notifierSignals) signal(objID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watched[objID] {
+		s.pending[objID] = true
+	}
+}
+
+// watch starts or stops accepting a notifier's callbacks.
+func (s *notifierSignals) watch(objID int64, watched bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if watched {
+		s.watched[objID] = true
+	} else {
+		delete(s.watched, objID)
+		delete(s.pending, objID)
+	}
+}
+
+// take moves the pending signals into marks.
+func (s *notifierSignals) take(marks map[int64]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	maps.Copy(marks, s.pending)
+	clear(s.pending)
+}
+
+// watchNotifier subscribes to a held object if it is a ChangeNotifier that is
+// not subscribed to yet, so re-resolving to the same object never subscribes
+// twice. The callback does nothing once the object is unwatched.
+// Sequence: seq-change-notifier.md
+func (t *Tracker) watchNotifier(obj any, objID int64) {
+	if t.notifiers[objID] != nil {
+		return
+	}
+	n, ok := obj.(ChangeNotifier)
+	if !ok {
+		return
+	}
+	signals := t.signals
+	signals.watch(objID, true)
+	cancel := n.OnChange(func() { signals.signal(objID) })
+	if cancel == nil {
+		cancel = func() {}
+	}
+	t.notifiers[objID] = cancel
+}
+
+// unwatchNotifier unsubscribes from a notifier that no variable holds any
+// more, or that was unregistered.
+func (t *Tracker) unwatchNotifier(objID int64) {
+	if cancel := t.notifiers[objID]; cancel != nil {
+		delete(t.notifiers, objID)
+		t.signals.watch(objID, false)
+		cancel()
+	}
+}
+
+// quiet reports whether v holds a notifier that has not signalled and v was
+// not marked, so its children need not be polled.
+func (t *Tracker) quiet(v *Variable) bool {
+	return t.notifiers[v.objID] != nil && !t.signalled[v.objID] && !t.forced[v.ID]
+}
+
+// underQuiet reports whether a variable is active and below a quiet
+// variable, so the polling walk did not reach it.
+func (t *Tracker) underQuiet(id int64) bool {
+	v := t.variables[id]
+	if v == nil || !v.Active {
+		return false
+	}
+	found := false
+	for p := t.variables[v.ParentID]; p != nil; p = t.variables[p.ParentID] {
+		if !p.Active {
+			return false
+		}
+		found = found || t.quiet(p)
+	}
+	return found
+}
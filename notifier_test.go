@@ -0,0 +1,176 @@
+package changetracker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// ============================================================================
+// Change Notifier Tests (test-ChangeNotifier.md)
+// ============================================================================
+
+// counter reports its own changes.
+type counter struct {
+	Count     int
+	listeners map[int]func()
+	next      int
+}
+
+func (c *counter) OnChange(fn func()) func() {
+	if c.listeners == nil {
+		c.listeners = make(map[int]func())
+	}
+	id := c.next
+	c.next++
+	c.listeners[id] = fn
+	return func() { delete(c.listeners, id) }
+}
+
+func (c *counter) Inc() {
+	c.Count++
+	for _, fn := range c.listeners {
+		fn()
+	}
+}
+
+type board struct {
+	Counter *counter
+}
+
+// NT1.1: a held notifier is subscribed to once, until no variable holds it
+func TestNotifier_Register(t *testing.T) {
+	tr := NewTracker()
+	b := &board{Counter: &counter{}}
+	c := b.Counter
+	root := tr.CreateVariable(b, 0, "", nil)
+	first := tr.CreateVariable(nil, root.ID, "Counter", nil)
+	second := tr.CreateVariable(nil, root.ID, "Counter", nil)
+	tr.RegisterObject(c)
+	tr.RegisterObject(&Person{}) // not a notifier
+	if len(c.listeners) != 1 {
+		t.Errorf("expected one subscription, got %d", len(c.listeners))
+	}
+
+	// Destroying one holder keeps the object and its subscription
+	tr.DestroyVariable(first.ID)
+	tr.DetectChanges()
+	if _, ok := tr.LookupObject(c); !ok || len(c.listeners) != 1 {
+		t.Errorf("expected one subscription to the held object, got %d (registered %v)", len(c.listeners), ok)
+	}
+
+	// The last holder's destruction unsubscribes
+	tr.DestroyVariable(second.ID)
+	if len(c.listeners) != 0 || len(tr.notifiers) != 0 {
+		t.Errorf("expected no subscription without holders, got %d", len(c.listeners))
+	}
+}
+
+// NT1.2: a notifier's children are polled only after it signals
+func TestNotifier_SkipsQuiet(t *testing.T) {
+	tr := NewTracker()
+	c := &counter{}
+	root := tr.CreateVariable(c, 0, "", nil)
+	count := tr.CreateVariable(nil, root.ID, "Count", nil)
+	p := &Person{Name: "Alice"}
+	other := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, other.ID, "Name", nil)
+	tr.GetChanges()
+
+	c.Count = 5 // silent
+	p.Name = "Bob"
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[name.ID] {
+		t.Errorf("expected only the polled variable to change, got %v", ids)
+	}
+	c.Inc()
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[count.ID] || count.ValueJSON != 6 {
+		t.Errorf("expected the notified change, got %v (%v)", ids, count.ValueJSON)
+	}
+	c.Inc()
+	if !tr.DetectDirty() || count.ValueJSON != 7 {
+		t.Errorf("expected DetectDirty to find the notified change, got %v", count.ValueJSON)
+	}
+}
+
+// NT1.3: replaced notifiers and marked variables are still checked
+func TestNotifier_Replaced(t *testing.T) {
+	tr := NewTracker()
+	b := &board{Counter: &counter{}}
+	root := tr.CreateVariable(b, 0, "", nil)
+	holder := tr.CreateVariable(nil, root.ID, "Counter", nil)
+	count := tr.CreateVariable(nil, holder.ID, "Count", nil)
+	tr.GetChanges()
+
+	// The holder's value changes, so its children are polled
+	b.Counter = &counter{Count: 3}
+	tr.DetectChanges()
+	if count.ValueJSON != 3 {
+		t.Errorf("expected the new counter to be read, got %v", count.ValueJSON)
+	}
+
+	// A marked variable under a quiet notifier is checked by the full walk
+	b.Counter.Count = 4
+	tr.MarkVariableDirty(count.ID)
+	tr.DetectChanges()
+	if count.ValueJSON != 4 {
+		t.Errorf("expected the marked variable to be checked, got %v", count.ValueJSON)
+	}
+
+	// Unregistered notifiers no longer mark anything
+	old := b.Counter
+	tr.UnregisterObject(old)
+	old.Inc()
+	if len(tr.signals.pending) != 0 {
+		t.Errorf("expected no marks from an unregistered notifier, got %v", tr.signals.pending)
+	}
+}
+
+// ticker is a notifier that changes and calls back from its own goroutine.
+type ticker struct {
+	n        atomic.Int64
+	listener func()
+}
+
+func (k *ticker) OnChange(fn func()) func() {
+	k.listener = fn
+	return func() {}
+}
+
+func (k *ticker) Ticks() int64 { return k.n.Load() }
+
+// NT1.4: notifiers may call back from other goroutines during detection
+func TestNotifier_Concurrent(t *testing.T) {
+	s := NewSyncTracker(nil)
+	k := &ticker{}
+	root := s.CreateVariable(k, 0, "", nil)
+	ticks := s.CreateVariable(nil, root.ID, "Ticks()", nil)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 1000 {
+			k.n.Add(1)
+			k.listener()
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		s.DetectChanges()
+	}
+	s.Do(func(*Tracker) {
+		if ticks.ValueJSON != int64(1000) {
+			t.Errorf("expected the last signal to be seen, got %v", ticks.ValueJSON)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// CRC: crc-PollSchedule.md
+// Spec: api.md
+package changetracker
+
+import "time"
+
+// Clock tells the time and waits for it. Set Tracker.Clock or Runner.Clock
+// to control polling schedules and detection loops, for example in tests;
+// nil uses the system clock.
+// CRC: crc-PollSchedule.md
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock used when none is set.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOr returns c, or the system clock if c is nil.
+func clockOr(c Clock) Clock {
+	if c == nil {
+		return systemClock{}
+	}
+	return c
+}
+
+// now returns the tracker clock's time.
+func (t *Tracker) now() time.Time {
+	return clockOr(t.Clock).Now()
+}
+
+// parsePoll parses a poll property value: a non-negative Go duration
+// ("500ms", "5s"); empty means every pass.
+func parsePoll(value string) (time.Duration, error) {
+	return parseDuration("poll", value, BadPollValue)
+}
+
+// parseDuration parses a duration property value; empty means 0.
+func parseDuration(name, value string, typ VariableErrorType) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, verror(typ, "invalid %s value %q (must be a duration like 500ms or 5s)", name, value)
+	}
+	return d, nil
+}
+
+// due reports whether a variable should be read in this pass, and if so
+// starts its next interval. Variables without an interval, and variables
+// marked or holding a signalled notifier, are always due.
+// Sequence: seq-detect-changes.md
+func (t *Tracker) due(v *Variable) bool {
+	if v.pollInterval == 0 || t.forced[v.ID] || t.signalled[v.objID] {
+		return true
+	}
+	now := t.now()
+	if now.Sub(v.lastPoll) < v.pollInterval {
+		return false
+	}
+	v.lastPoll = now
+	return true
+}
+
+// scheduled returns the active descendants of a variable that is not due
+// which have their own poll property, passing through the descendants that
+// follow its schedule.
+func (t *Tracker) scheduled(v *Variable) []int64 {
+	var ids []int64
+	for _, childID := range v.ChildIDs {
+		child := t.variables[childID]
+		if child == nil || !child.Active {
+			continue
+		}
+		if child.Properties["poll"] != "" {
+			ids = append(ids, childID)
+		} else {
+			ids = append(ids, t.scheduled(child)...)
+		}
+	}
+	return ids
+}
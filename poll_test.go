@@ -0,0 +1,93 @@
+package changetracker
+
+import (
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Poll Schedule Tests (test-PollSchedule.md)
+// ============================================================================
+
+// PL1.1: a variable is read only once its interval has elapsed
+func TestPoll_Interval(t *testing.T) {
+	tr := NewTracker()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tr.Clock = clock
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name?poll=5s", nil)
+	tr.GetChanges()
+
+	p.Name = "Bob"
+	clock.advance(4 * time.Second)
+	if tr.DetectChanges() {
+		t.Error("expected the variable to be skipped before its interval")
+	}
+	clock.advance(time.Second)
+	if !tr.DetectChanges() || name.ValueJSON != "Bob" {
+		t.Errorf("expected the change once the interval elapsed, got %v", name.ValueJSON)
+	}
+	// The interval restarts at each read
+	p.Name = "Carol"
+	clock.advance(4 * time.Second)
+	if tr.DetectChanges() {
+		t.Error("expected the next interval to start at the last read")
+	}
+}
+
+// PL1.2: children follow their parent's schedule unless they have their own
+func TestPoll_Children(t *testing.T) {
+	tr := NewTracker()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tr.Clock = clock
+	p := &Person{Address: &Address{City: "Paris", Country: "France"}}
+	root := tr.CreateVariable(p, 0, "", nil)
+	addr := tr.CreateVariable(nil, root.ID, "Address?poll=10s", nil)
+	city := tr.CreateVariable(nil, addr.ID, "City", nil)
+	country := tr.CreateVariable(nil, addr.ID, "Country?poll=1s", nil)
+	tr.GetChanges()
+
+	p.Address.City = "Lyon"
+	p.Address.Country = "Italy"
+	clock.advance(time.Second)
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[country.ID] {
+		t.Errorf("expected only the child with its own schedule, got %v", ids)
+	}
+	clock.advance(9 * time.Second)
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[city.ID] {
+		t.Errorf("expected the inheriting child with its parent, got %v", ids)
+	}
+}
+
+// PL1.3: poll values are validated; marks and removal override the schedule
+func TestPoll_Property(t *testing.T) {
+	tr := NewTracker()
+	tr.Clock = &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	if _, err := tr.TryCreateVariable(nil, root.ID, "Name?poll=soon", nil); errorType(err) != BadPollValue {
+		t.Errorf("expected BadPollValue, got %v", err)
+	}
+	name := tr.CreateVariable(nil, root.ID, "Name?poll=1m", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age?poll=1m", nil)
+	if err := name.TrySetProperty("poll", "-1s"); errorType(err) != BadPollValue || name.GetProperty("poll") != "1m" {
+		t.Errorf("expected BadPollValue leaving the property, got %v", err)
+	}
+	tr.GetChanges()
+
+	p.Name = "Bob"
+	p.Age = 31
+	name.SetProperty("poll", "")
+	tr.MarkVariableDirty(age.ID)
+	tr.DetectDirty()
+	if name.ValueJSON != "Alice" || age.ValueJSON != 31 {
+		t.Errorf("expected only the marked variable to be read early, got %v, %v", name.ValueJSON, age.ValueJSON)
+	}
+	tr.DetectChanges()
+	if name.ValueJSON != "Bob" {
+		t.Errorf("expected removing poll to read every pass, got %v", name.ValueJSON)
+	}
+}
@@ -0,0 +1,421 @@
+// CRC: crc-Mirror.md
+// Spec: protocol.md
+package protocol
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	changetracker "github.com/zot/change-tracker"
+)
+
+// MirrorErrorType categorizes inconsistencies found by a Mirror.
+// CRC: crc-Mirror.md
+type MirrorErrorType int
+
+const (
+	UnknownVariable   MirrorErrorType = iota // message refers to a variable the mirror does not have
+	DuplicateVariable                        // create for an ID the mirror already has
+	UnknownParent                            // variable's parent is not in the mirror
+	BadValue                                 // value is not valid Value JSON
+	BadArrayOps                              // array operations do not apply or do not produce the sent value
+	DanglingRef                              // {"obj": n} reference to an object no variable holds
+	UnexpectedMessage                        // message type the mirror cannot apply
+	ValueMismatch                            // mirror value differs from the tracker's
+	PropertyMismatch                         // mirror properties differ from the tracker's
+	MissingVariable                          // tracker variable absent from the mirror
+	ExtraVariable                            // mirror variable absent from the tracker
+	ParentMismatch                           // mirror parent differs from the tracker's
+)
+
+func (e MirrorErrorType) String() string {
+	return [...]string{
+		"UnknownVariable",
+		"DuplicateVariable",
+		"UnknownParent",
+		"BadValue",
+		"BadArrayOps",
+		"DanglingRef",
+		"UnexpectedMessage",
+		"ValueMismatch",
+		"PropertyMismatch",
+		"MissingVariable",
+		"ExtraVariable",
+		"ParentMismatch",
+	}[e]
+}
+
+// MirrorError reports one inconsistency for a variable.
+// CRC: crc-Mirror.md
+type MirrorError struct {
+	ErrorType MirrorErrorType
+	ID        int64 // variable ID
+	Message   string
+}
+
+func merror(typ MirrorErrorType, id int64, msg string, args ...any) *MirrorError {
+	return &MirrorError{ErrorType: typ, ID: id, Message: fmt.Sprintf("%s error: variable %d: "+msg, append([]any{typ, id}, args...)...)}
+}
+
+func (e *MirrorError) Error() string {
+	return e.Message
+}
+
+// MirrorVariable is the client-side view of a variable.
+// CRC: crc-Mirror.md
+type MirrorVariable struct {
+	ID         int64
+	ParentID   int64
+	ChildIDs   []int64
+	ValueJSON  any // Value JSON with {"obj": n} decoded as ObjectRef
+	Properties map[string]string
+}
+
+// Mirror rebuilds a tracker's variable tree from protocol messages, the way a
+// frontend sees it. It applies messages in both directions: server create,
+// destroy and update messages, and the client's own create, set-property and
+// set-value messages.
+// CRC: crc-Mirror.md
+type Mirror struct {
+	Variables    map[int64]*MirrorVariable
+	ServerErrors []*Message // error messages received from the server
+
+	refs map[int64]map[int64]bool // object ID -> variables whose value references it
+}
+
+// NewMirror creates an empty mirror.
+func NewMirror() *Mirror {
+	return &Mirror{
+		Variables: make(map[int64]*MirrorVariable),
+		refs:      make(map[int64]map[int64]bool),
+	}
+}
+
+// Consume applies messages from d until EOF.
+// Inconsistencies are collected and returned together; decoding errors stop reading.
+func (m *Mirror) Consume(d *Decoder) error {
+	var errs []error
+	for {
+		msg, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return errors.Join(errs...)
+		} else if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if err := m.Apply(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// Apply applies one message. Errors are *MirrorError values (joined for update batches).
+func (m *Mirror) Apply(msg *Message) error {
+	switch msg.Type {
+	case Create:
+		return m.create(msg)
+	case Destroy:
+		return m.destroy(msg.ID)
+	case Update:
+		var errs []error
+		for i := range msg.Updates {
+			if err := m.update(&msg.Updates[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	case SetProperty:
+		v := m.Variables[msg.ID]
+		if v == nil {
+			return merror(UnknownVariable, msg.ID, "set-property for unknown variable")
+		}
+		setProperties(v, msg.Properties)
+	case SetValue:
+		v := m.Variables[msg.ID]
+		if v == nil {
+			return merror(UnknownVariable, msg.ID, "set-value for unknown variable")
+		}
+		value, err := parseValueJSON(msg.Value)
+		if err != nil {
+			return merror(BadValue, msg.ID, "%v", err)
+		}
+		m.setValue(v, value)
+	case Error:
+		m.ServerErrors = append(m.ServerErrors, msg)
+	default:
+		return merror(UnexpectedMessage, msg.ID, "unexpected message type %q", msg.Type)
+	}
+	return nil
+}
+
+func (m *Mirror) create(msg *Message) error {
+	if m.Variables[msg.ID] != nil {
+		return merror(DuplicateVariable, msg.ID, "variable already exists")
+	}
+	var parent *MirrorVariable
+	if msg.ParentID != 0 {
+		if parent = m.Variables[msg.ParentID]; parent == nil {
+			return merror(UnknownParent, msg.ID, "parent %d not found", msg.ParentID)
+		}
+	}
+	value, err := parseValueJSON(msg.Value)
+	if err != nil {
+		return merror(BadValue, msg.ID, "%v", err)
+	}
+	v := &MirrorVariable{ID: msg.ID, ParentID: msg.ParentID, Properties: maps.Clone(msg.Properties)}
+	if v.Properties == nil {
+		v.Properties = make(map[string]string)
+	}
+	if path, _, _ := strings.Cut(msg.Path, "?"); path != "" && v.Properties["path"] == "" {
+		v.Properties["path"] = path
+	}
+	m.Variables[v.ID] = v
+	if parent != nil {
+		parent.ChildIDs = append(parent.ChildIDs, v.ID)
+	}
+	m.setValue(v, value)
+	return nil
+}
+
+func (m *Mirror) destroy(id int64) error {
+	v := m.Variables[id]
+	if v == nil {
+		return merror(UnknownVariable, id, "destroy for unknown variable")
+	}
+	if parent := m.Variables[v.ParentID]; parent != nil {
+		parent.ChildIDs = slices.DeleteFunc(parent.ChildIDs, func(c int64) bool { return c == id })
+	}
+	m.setValue(v, nil)
+	delete(m.Variables, id)
+	return nil
+}
+
+func (m *Mirror) update(u *VariableUpdate) error {
+	v := m.Variables[u.ID]
+	if v == nil {
+		return merror(UnknownVariable, u.ID, "update for unknown variable")
+	}
+	if u.ParentID != nil {
+		if err := m.move(v, *u.ParentID); err != nil {
+			return err
+		}
+	}
+	setProperties(v, u.Properties)
+	if len(u.Value) == 0 && len(u.ArrayOps) == 0 {
+		return nil
+	}
+	value, err := parseValueJSON(u.Value)
+	if err != nil {
+		return merror(BadValue, u.ID, "%v", err)
+	}
+	if len(u.ArrayOps) > 0 {
+		old, ok := v.ValueJSON.([]any)
+		if !ok {
+			return merror(BadArrayOps, u.ID, "array operations on non-array value %v", v.ValueJSON)
+		}
+		ops := slices.Clone(u.ArrayOps)
+		for i := range ops {
+			// Inserted values arrive as plain JSON
+			if ops[i].Value, err = parseRef(ops[i].Value); err != nil {
+				return merror(BadValue, u.ID, "%v", err)
+			}
+		}
+		patched, err := changetracker.ApplyArrayOps(old, ops)
+		if err != nil {
+			return merror(BadArrayOps, u.ID, "%v", err)
+		}
+		if len(u.Value) == 0 {
+			value = patched
+		} else if !reflect.DeepEqual(patched, value) {
+			m.setValue(v, value)
+			return merror(BadArrayOps, u.ID, "array operations produced %v, expected %v", patched, value)
+		}
+	}
+	m.setValue(v, value)
+	return nil
+}
+
+// move reparents a variable, keeping both parents' ChildIDs current.
+func (m *Mirror) move(v *MirrorVariable, parentID int64) error {
+	var parent *MirrorVariable
+	if parentID != 0 {
+		if parent = m.Variables[parentID]; parent == nil {
+			return merror(UnknownParent, v.ID, "parent %d not found", parentID)
+		}
+	}
+	if old := m.Variables[v.ParentID]; old != nil {
+		old.ChildIDs = slices.DeleteFunc(old.ChildIDs, func(c int64) bool { return c == v.ID })
+	}
+	v.ParentID = parentID
+	if parent != nil {
+		parent.ChildIDs = append(parent.ChildIDs, v.ID)
+	}
+	return nil
+}
+
+func setProperties(v *MirrorVariable, props map[string]string) {
+	for name, value := range props {
+		if value == "" {
+			delete(v.Properties, name)
+		} else {
+			v.Properties[name] = value
+		}
+	}
+}
+
+// setValue replaces a variable's value and updates the object reference graph.
+func (m *Mirror) setValue(v *MirrorVariable, value any) {
+	for _, id := range objectRefs(v.ValueJSON) {
+		if referrers := m.refs[id]; referrers != nil {
+			delete(referrers, v.ID)
+			if len(referrers) == 0 {
+				delete(m.refs, id)
+			}
+		}
+	}
+	v.ValueJSON = value
+	for _, id := range objectRefs(value) {
+		if m.refs[id] == nil {
+			m.refs[id] = make(map[int64]bool)
+		}
+		m.refs[id][v.ID] = true
+	}
+}
+
+// Referrers returns the IDs of variables whose value references object objID.
+func (m *Mirror) Referrers(objID int64) []int64 {
+	return slices.Sorted(maps.Keys(m.refs[objID]))
+}
+
+// Objects returns the IDs of all objects referenced by variable values.
+func (m *Mirror) Objects() []int64 {
+	return slices.Sorted(maps.Keys(m.refs))
+}
+
+// Check validates the mirror's structure: every variable's parent exists, and
+// every object reference inside an array is also the whole value of some
+// variable, so the client can reach the object's contents.
+func (m *Mirror) Check() []error {
+	var errs []error
+	held := make(map[int64]bool)
+	for _, v := range m.Variables {
+		if ref, ok := v.ValueJSON.(changetracker.ObjectRef); ok {
+			held[ref.Obj] = true
+		}
+	}
+	for _, v := range m.sortedVariables() {
+		if v.ParentID != 0 && m.Variables[v.ParentID] == nil {
+			errs = append(errs, merror(UnknownParent, v.ID, "parent %d not found", v.ParentID))
+		}
+		if elems, ok := v.ValueJSON.([]any); ok {
+			for i, elem := range elems {
+				if ref, ok := elem.(changetracker.ObjectRef); ok && !held[ref.Obj] {
+					errs = append(errs, merror(DanglingRef, v.ID, "element %d references object %d, which no variable holds", i, ref.Obj))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// Compare reports differences between the mirror and t.
+// A variable's expected value is its WrapperJSON if it has one, otherwise its ValueJSON.
+// The caller must hold any lock that protects t.
+func (m *Mirror) Compare(t *changetracker.Tracker) []error {
+	var errs []error
+	vars := t.Variables()
+	slices.SortFunc(vars, func(a, b *changetracker.Variable) int { return cmp.Compare(a.ID, b.ID) })
+	for _, tv := range vars {
+		mv := m.Variables[tv.ID]
+		if mv == nil {
+			errs = append(errs, merror(MissingVariable, tv.ID, "not in mirror"))
+			continue
+		}
+		raw, err := json.Marshal(outgoingJSON(tv))
+		if err != nil {
+			errs = append(errs, merror(BadValue, tv.ID, "%v", err))
+			continue
+		}
+		expected, err := parseValueJSON(raw)
+		if err != nil {
+			errs = append(errs, merror(BadValue, tv.ID, "%v", err))
+		} else if !reflect.DeepEqual(expected, mv.ValueJSON) {
+			errs = append(errs, merror(ValueMismatch, tv.ID, "mirror has %v, tracker has %v", mv.ValueJSON, expected))
+		}
+		if tv.ParentID != mv.ParentID {
+			errs = append(errs, merror(ParentMismatch, tv.ID, "mirror has parent %d, tracker has %d", mv.ParentID, tv.ParentID))
+		}
+		if !maps.Equal(tv.Properties, mv.Properties) {
+			errs = append(errs, merror(PropertyMismatch, tv.ID, "mirror has %v, tracker has %v", mv.Properties, tv.Properties))
+		}
+	}
+	for _, mv := range m.sortedVariables() {
+		if t.GetVariable(mv.ID) == nil {
+			errs = append(errs, merror(ExtraVariable, mv.ID, "not in tracker"))
+		}
+	}
+	return errs
+}
+
+func (m *Mirror) sortedVariables() []*MirrorVariable {
+	return slices.SortedFunc(maps.Values(m.Variables), func(a, b *MirrorVariable) int { return cmp.Compare(a.ID, b.ID) })
+}
+
+// parseValueJSON decodes Value JSON, turning {"obj": n} into ObjectRef.
+// Empty input decodes to nil. Objects other than references and nested arrays are rejected.
+func parseValueJSON(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	if arr, ok := value.([]any); ok {
+		for i, elem := range arr {
+			if _, nested := elem.([]any); nested {
+				return nil, fmt.Errorf("nested array at element %d", i)
+			}
+			var err error
+			if arr[i], err = parseRef(elem); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return parseRef(value)
+}
+
+func parseRef(value any) (any, error) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return value, nil
+	}
+	if id, ok := obj["obj"].(float64); ok && len(obj) == 1 {
+		return changetracker.ObjectRef{Obj: int64(id)}, nil
+	}
+	return nil, fmt.Errorf("inline object %v is not an object reference", obj)
+}
+
+// objectRefs returns the object IDs referenced by a Value JSON value.
+func objectRefs(value any) []int64 {
+	switch v := value.(type) {
+	case changetracker.ObjectRef:
+		return []int64{v.Obj}
+	case []any:
+		var ids []int64
+		for _, elem := range v {
+			if ref, ok := elem.(changetracker.ObjectRef); ok {
+				ids = append(ids, ref.Obj)
+			}
+		}
+		return ids
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	changetracker "github.com/zot/change-tracker"
+)
+
+// ============================================================================
+// Mirror Tests (test-Mirror.md)
+// ============================================================================
+
+func errorTypes(err error) []MirrorErrorType {
+	var types []MirrorErrorType
+	var walk func(error)
+	walk = func(err error) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				walk(e)
+			}
+			return
+		}
+		var me *MirrorError
+		if errors.As(err, &me) {
+			types = append(types, me.ErrorType)
+		}
+	}
+	if err != nil {
+		walk(err)
+	}
+	return types
+}
+
+func expectError(t *testing.T, err error, typ MirrorErrorType) {
+	t.Helper()
+	types := errorTypes(err)
+	if len(types) != 1 || types[0] != typ {
+		t.Errorf("expected %s, got %v", typ, err)
+	}
+}
+
+// M1.1: structural errors while applying messages
+func TestMirror_ApplyErrors(t *testing.T) {
+	m := NewMirror()
+	if err := m.Apply(&Message{Type: Create, ID: 1, Value: json.RawMessage(`{"obj": 2}`)}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	expectError(t, m.Apply(&Message{Type: Create, ID: 1}), DuplicateVariable)
+	expectError(t, m.Apply(&Message{Type: Create, ID: 3, ParentID: 9}), UnknownParent)
+	expectError(t, m.Apply(&Message{Type: Create, ID: 4, Value: json.RawMessage(`{"name": "x"}`)}), BadValue)
+	expectError(t, m.Apply(&Message{Type: Create, ID: 5, Value: json.RawMessage(`[[1]]`)}), BadValue)
+	expectError(t, m.Apply(&Message{Type: Update, Updates: []VariableUpdate{{ID: 1}, {ID: 7, Value: json.RawMessage(`1`)}}}), UnknownVariable)
+	expectError(t, m.Apply(&Message{Type: Destroy, ID: 7}), UnknownVariable)
+	expectError(t, m.Apply(&Message{Type: SetValue, ID: 7, Value: json.RawMessage(`1`)}), UnknownVariable)
+	expectError(t, m.Apply(&Message{Type: "bogus"}), UnexpectedMessage)
+	if err := m.Apply(&Message{Type: Error, ErrorType: "NotFound"}); err != nil || len(m.ServerErrors) != 1 {
+		t.Errorf("expected server error to be recorded, got %v", err)
+	}
+}
+
+// M1.2: array operations are applied and checked against the sent value
+func TestMirror_ArrayOps(t *testing.T) {
+	m := NewMirror()
+	m.Apply(&Message{Type: Create, ID: 1, Value: json.RawMessage(`[{"obj": 2}]`)})
+	// Inserted values arrive as plain JSON objects
+	var ops []changetracker.ArrayOp
+	json.Unmarshal([]byte(`[{"op": "insert", "index": 1, "value": {"obj": 3}}]`), &ops)
+	err := m.Apply(&Message{Type: Update, Updates: []VariableUpdate{{
+		ID:       1,
+		Value:    json.RawMessage(`[{"obj": 2}, {"obj": 3}]`),
+		ArrayOps: ops,
+	}}})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	err = m.Apply(&Message{Type: Update, Updates: []VariableUpdate{{
+		ID:       1,
+		Value:    json.RawMessage(`[{"obj": 2}]`),
+		ArrayOps: []changetracker.ArrayOp{{Op: changetracker.ArrayRemove, Index: 0}},
+	}}})
+	expectError(t, err, BadArrayOps)
+	if len(m.Variables[1].ValueJSON.([]any)) != 1 {
+		t.Error("mirror should take the sent value after a mismatch")
+	}
+	err = m.Apply(&Message{Type: Update, Updates: []VariableUpdate{{
+		ID:       1,
+		ArrayOps: []changetracker.ArrayOp{{Op: changetracker.ArrayRemove, Index: 5}},
+	}}})
+	expectError(t, err, BadArrayOps)
+}
+
+// M1.3: object reference graph and dangling references
+func TestMirror_RefsAndCheck(t *testing.T) {
+	m := NewMirror()
+	m.Apply(&Message{Type: Create, ID: 1, Value: json.RawMessage(`[{"obj": 10}, {"obj": 11}]`)})
+	m.Apply(&Message{Type: Create, ID: 2, ParentID: 1, Path: "0?x=1", Value: json.RawMessage(`{"obj": 10}`)})
+	if got := m.Referrers(10); len(got) != 2 {
+		t.Errorf("expected 2 referrers of object 10, got %v", got)
+	}
+	if m.Variables[2].Properties["path"] != "0" {
+		t.Errorf("expected path 0, got %q", m.Variables[2].Properties["path"])
+	}
+	errs := m.Check()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 dangling reference, got %v", errs)
+	}
+	expectError(t, errs[0], DanglingRef)
+
+	m.Apply(&Message{Type: Destroy, ID: 2})
+	if got := m.Referrers(10); len(got) != 1 {
+		t.Errorf("expected 1 referrer after destroy, got %v", got)
+	}
+	if len(m.Variables[1].ChildIDs) != 0 {
+		t.Error("destroyed child should be removed from parent")
+	}
+}
+
+// M2.1: a mirror fed by a Session matches the tracker
+func TestMirror_Session(t *testing.T) {
+	c := newClient(t)
+	m := NewMirror()
+	alice, bob := &Person{Name: "Alice"}, &Person{Name: "Bob"}
+	data := &Team{Lead: alice, Members: []*Person{alice}}
+	root := c.tracker.CreateVariable(data, 0, "", nil)
+	c.tracker.CreateVariable(nil, root.ID, "Members?diff=array", nil)
+	lead := c.tracker.CreateVariable(nil, root.ID, "Lead", nil)
+
+	// drain sends one update cycle plus a marker, applying messages up to the marker
+	drain := func() {
+		t.Helper()
+		c.sendUpdates()
+		go c.session.enc.Encode(&Message{Type: "marker"})
+		for msg := c.receive(); msg.Type != "marker"; msg = c.receive() {
+			if err := m.Apply(msg); err != nil {
+				t.Fatalf("apply %+v: %v", msg, err)
+			}
+		}
+	}
+	compare := func() {
+		t.Helper()
+		c.tracker.Do(func(tr *changetracker.Tracker) {
+			for _, err := range m.Compare(tr) {
+				t.Error(err)
+			}
+		})
+	}
+	drain()
+	compare()
+
+	// Client-created variable: apply locally, then receive its initial state
+	create := &Message{Type: Create, ID: 100, ParentID: root.ID, Path: "Lead.Name?label=Name"}
+	m.Apply(create)
+	c.send(create)
+	c.waitFor(exists(100))
+	drain()
+	compare()
+
+	c.tracker.Do(func(*changetracker.Tracker) {
+		data.Members = append(data.Members, bob)
+		alice.Name = "Alicia"
+	})
+	drain()
+	compare()
+	if errs := m.Check(); len(errs) != 1 {
+		// bob is only referenced from the array
+		t.Errorf("expected 1 dangling reference, got %v", errs)
+	}
+
+	// Server-side move of the client's variable
+	if err := c.tracker.MoveVariable(100, lead.ID, "Name"); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	drain()
+	compare()
+	if m.Variables[100].ParentID != lead.ID || len(m.Variables[root.ID].ChildIDs) != 2 || len(m.Variables[lead.ID].ChildIDs) != 1 {
+		t.Errorf("expected 100 under lead, got %+v", m.Variables[100])
+	}
+}
+
+// M1.4: moves reparent mirror variables; Compare reports parent differences
+func TestMirror_Move(t *testing.T) {
+	m := NewMirror()
+	m.Apply(&Message{Type: Create, ID: 1})
+	m.Apply(&Message{Type: Create, ID: 2, ParentID: 1})
+	parent := int64(0)
+	if err := m.Apply(&Message{Type: Update, Updates: []VariableUpdate{{ID: 2, ParentID: &parent}}}); err != nil {
+		t.Fatalf("move failed: %v", err)
+	}
+	if m.Variables[2].ParentID != 0 || len(m.Variables[1].ChildIDs) != 0 {
+		t.Errorf("expected 2 to be a root, got %+v", m.Variables[2])
+	}
+	parent = 9
+	expectError(t, m.Apply(&Message{Type: Update, Updates: []VariableUpdate{{ID: 2, ParentID: &parent}}}), UnknownParent)
+
+	tr := changetracker.NewTracker()
+	tr.CreateVariableWithId(1, nil, 0, "", nil)
+	tr.CreateVariableWithId(2, nil, 1, "", nil)
+	errs := m.Compare(tr)
+	if len(errs) != 1 || errs[0].(*MirrorError).ErrorType != ParentMismatch {
+		t.Errorf("expected ParentMismatch, got %v", errs)
+	}
+}
@@ -0,0 +1,128 @@
+// Package protocol defines a versioned JSON wire format for tracker sessions.
+// CRC: crc-Message.md, crc-Session.md
+// Spec: protocol.md
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	changetracker "github.com/zot/change-tracker"
+)
+
+// Version is the protocol version written on every message.
+const Version = 1
+
+// MessageType identifies the kind of a message.
+// CRC: crc-Message.md
+type MessageType string
+
+const (
+	Create      MessageType = "create"       // create a variable (client or server)
+	Destroy     MessageType = "destroy"      // destroy a variable (client or server)
+	Update      MessageType = "update"       // batch of variable updates (server)
+	SetProperty MessageType = "set-property" // set variable properties (client)
+	SetValue    MessageType = "set-value"    // set a variable's value (client)
+	Error       MessageType = "error"        // a request failed (server)
+)
+
+// ProtocolError is the ErrorType of errors that are not tracker errors,
+// such as unsupported versions or unknown message types.
+const ProtocolError = "ProtocolError"
+
+// Message is a single protocol message. Which fields are used depends on Type:
+//   - create: ID, ParentID, Path, Properties, Value (root variables only from clients)
+//   - destroy: ID
+//   - update: Updates
+//   - set-property: ID, Properties ("" removes a property)
+//   - set-value: ID, Value
+//   - error: ID (if the error concerns a variable), ErrorType, Message
+//
+// CRC: crc-Message.md
+type Message struct {
+	Version    int               `json:"v"`
+	Type       MessageType       `json:"type"`
+	ID         int64             `json:"id,omitempty"`
+	ParentID   int64             `json:"parentId,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Value      json.RawMessage   `json:"value,omitempty"` // Value JSON
+	Updates    []VariableUpdate  `json:"updates,omitempty"`
+	ErrorType  string            `json:"errorType,omitempty"`
+	Message    string            `json:"message,omitempty"`
+}
+
+// VariableUpdate describes the changes to one variable in an update batch.
+// CRC: crc-Message.md
+type VariableUpdate struct {
+	ID         int64                   `json:"id"`
+	ParentID   *int64                  `json:"parentId,omitempty"` // new parent (0 for root), present only if the variable moved
+	Value      json.RawMessage         `json:"value,omitempty"`    // new Value JSON, present only if the value changed
+	ArrayOps   []changetracker.ArrayOp `json:"arrayOps,omitempty"` // array diff from the previous value, if available
+	Properties map[string]string       `json:"properties,omitempty"`
+}
+
+// Encoder writes newline-delimited messages. It is safe for concurrent use.
+// CRC: crc-Message.md
+type Encoder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{enc: json.NewEncoder(w)}
+}
+
+// Encode stamps msg with the protocol version and writes it.
+func (e *Encoder) Encode(msg *Message) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	msg.Version = Version
+	return e.enc.Encode(msg)
+}
+
+// Decoder reads messages written by an Encoder.
+// CRC: crc-Message.md
+type Decoder struct {
+	dec *json.Decoder
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// Decode reads the next message.
+// A message with an unsupported version is returned along with a *VersionError,
+// so callers can report it and keep reading.
+func (d *Decoder) Decode() (*Message, error) {
+	msg := &Message{}
+	if err := d.dec.Decode(msg); err != nil {
+		return nil, err
+	}
+	if msg.Version != Version {
+		return msg, &VersionError{Version: msg.Version}
+	}
+	return msg, nil
+}
+
+// VersionError reports a message with an unsupported protocol version.
+type VersionError struct {
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("unsupported protocol version %d (want %d)", e.Version, Version)
+}
+
+// errorMessage builds an error message for err, which may be a *VariableError.
+func errorMessage(id int64, err error) *Message {
+	msg := &Message{Type: Error, ID: id, ErrorType: ProtocolError, Message: err.Error()}
+	if ve, ok := err.(*changetracker.VariableError); ok {
+		msg.ErrorType = ve.ErrorType.String()
+	}
+	return msg
+}
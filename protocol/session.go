@@ -0,0 +1,283 @@
+// CRC: crc-Session.md
+// Spec: protocol.md
+package protocol
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	changetracker "github.com/zot/change-tracker"
+)
+
+// Session connects a tracker to one peer over a reader/writer pair.
+// Incoming client messages become tracker calls, and each SendUpdates cycle
+// becomes outgoing create, destroy and update messages.
+// CRC: crc-Session.md
+type Session struct {
+	tracker *changetracker.SyncTracker
+	cursor  *changetracker.Cursor // this session's changes, independent of other sessions
+	dec     *Decoder
+	enc     *Encoder
+
+	// Guarded by the tracker lock
+	known   map[int64]bool // variables the peer knows about
+	initial map[int64]bool // client-created variables that still need their initial state
+}
+
+// NewSession creates a session for tracker that reads client messages from r
+// and writes server messages to w.
+func NewSession(tracker *changetracker.SyncTracker, r io.Reader, w io.Writer) *Session {
+	return &Session{
+		tracker: tracker,
+		cursor:  tracker.NewCursor(0),
+		dec:     NewDecoder(r),
+		enc:     NewEncoder(w),
+		known:   make(map[int64]bool),
+		initial: make(map[int64]bool),
+	}
+}
+
+// Close stops retaining changes for the session.
+func (s *Session) Close() {
+	s.tracker.CloseCursor(s.cursor)
+}
+
+// Serve reads and handles client messages until the reader is exhausted.
+// Failed requests are answered with error messages. Returns nil at EOF.
+// Sequence: seq-session.md
+func (s *Session) Serve() error {
+	for {
+		msg, err := s.dec.Decode()
+		var verr *VersionError
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.As(err, &verr):
+			if err := s.enc.Encode(errorMessage(msg.ID, err)); err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+		if reply := s.Handle(msg); reply != nil {
+			if err := s.enc.Encode(reply); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Handle applies one client message to the tracker.
+// Returns the error message to send back, or nil on success.
+func (s *Session) Handle(msg *Message) *Message {
+	var err error
+	s.tracker.Do(func(t *changetracker.Tracker) {
+		err = s.handle(t, msg)
+	})
+	if err != nil {
+		return errorMessage(msg.ID, err)
+	}
+	return nil
+}
+
+func (s *Session) handle(t *changetracker.Tracker, msg *Message) (err error) {
+	// Resolvers and domain methods reached through paths may panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	if msg.Type == Create {
+		return s.create(t, msg)
+	}
+	v := t.GetVariable(msg.ID)
+	if v == nil {
+		return &changetracker.VariableError{
+			ErrorType: changetracker.NotFound,
+			Message:   fmt.Sprintf("NotFound error: variable %d not found", msg.ID),
+		}
+	}
+	switch msg.Type {
+	case Destroy:
+		// The peer drops the descendants too, so they must not be destroyed again
+		for _, id := range t.DestroySubtree(msg.ID) {
+			delete(s.known, id)
+			delete(s.initial, id)
+		}
+	case SetProperty:
+		for _, name := range slices.Sorted(maps.Keys(msg.Properties)) {
+			if err := v.TrySetProperty(name, msg.Properties[name]); err != nil {
+				return err
+			}
+		}
+	case SetValue:
+		value, err := t.FromValueJSONBytes(msg.Value)
+		if err != nil {
+			return err
+		}
+		return v.Set(value)
+	default:
+		return fmt.Errorf("unexpected message type %q", msg.Type)
+	}
+	return nil
+}
+
+// create handles a client create message.
+// A zero ID asks the server to assign one; the variable is then announced
+// with a create message on the next SendUpdates.
+func (s *Session) create(t *changetracker.Tracker, msg *Message) error {
+	var value any
+	if len(msg.Value) > 0 {
+		var err error
+		if value, err = t.FromValueJSONBytes(msg.Value); err != nil {
+			return err
+		}
+	}
+	if msg.ID == 0 {
+		_, err := t.TryCreateVariable(value, msg.ParentID, msg.Path, msg.Properties)
+		return err
+	}
+	if _, err := t.TryCreateVariableWithId(msg.ID, value, msg.ParentID, msg.Path, msg.Properties); err != nil {
+		return err
+	}
+	s.known[msg.ID] = true
+	s.initial[msg.ID] = true
+	return nil
+}
+
+// SendUpdates runs one detection cycle and writes the resulting messages:
+// destroy messages for variables that disappeared, create messages for
+// variables the peer has not seen, and one update batch for everything else.
+// Sequence: seq-session.md
+func (s *Session) SendUpdates() error {
+	var msgs []*Message
+	var err error
+	s.tracker.Do(func(t *changetracker.Tracker) {
+		t.DetectChanges()
+		changes, _ := s.cursor.Read() // unlimited cursors never overflow
+		msgs, err = s.collect(t, changes)
+	})
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if err := s.enc.Encode(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect builds the outgoing messages for one cycle.
+func (s *Session) collect(t *changetracker.Tracker, changes []changetracker.Change) ([]*Message, error) {
+	var msgs []*Message
+	for _, id := range slices.Sorted(maps.Keys(s.known)) {
+		if t.GetVariable(id) == nil {
+			msgs = append(msgs, &Message{Type: Destroy, ID: id})
+			delete(s.known, id)
+			delete(s.initial, id)
+		}
+	}
+
+	announced := make(map[int64]bool)
+	var announce func(v *changetracker.Variable) error
+	announce = func(v *changetracker.Variable) error {
+		if !s.known[v.ID] {
+			value, err := json.Marshal(outgoingJSON(v))
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, &Message{
+				Type:       Create,
+				ID:         v.ID,
+				ParentID:   v.ParentID,
+				Properties: maps.Clone(v.Properties),
+				Value:      value,
+			})
+			s.known[v.ID] = true
+			announced[v.ID] = true
+		}
+		for _, child := range t.Children(v.ID) {
+			if err := announce(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	roots := t.RootVariables()
+	slices.SortFunc(roots, func(a, b *changetracker.Variable) int { return cmp.Compare(a.ID, b.ID) })
+	for _, root := range roots {
+		if err := announce(root); err != nil {
+			return nil, err
+		}
+	}
+
+	var updates []VariableUpdate
+	index := make(map[int64]int)
+	update := func(id int64) *VariableUpdate {
+		i, ok := index[id]
+		if !ok {
+			i = len(updates)
+			index[id] = i
+			updates = append(updates, VariableUpdate{ID: id})
+		}
+		return &updates[i]
+	}
+	for _, id := range slices.Sorted(maps.Keys(s.initial)) {
+		v := t.GetVariable(id)
+		value, err := json.Marshal(outgoingJSON(v))
+		if err != nil {
+			return nil, err
+		}
+		u := update(id)
+		u.Value = value
+		u.Properties = maps.Clone(v.Properties)
+	}
+	for _, c := range changes {
+		v := t.GetVariable(c.VariableID)
+		if v == nil || announced[v.ID] || s.initial[v.ID] {
+			continue
+		}
+		u := update(v.ID)
+		if c.ParentChanged {
+			parentID := v.ParentID // the message is encoded after the lock is released
+			u.ParentID = &parentID
+		}
+		if c.ValueChanged {
+			value, err := json.Marshal(outgoingJSON(v))
+			if err != nil {
+				return nil, err
+			}
+			u.Value = value
+			if v.WrapperJSON == nil {
+				u.ArrayOps = c.ArrayOps
+			}
+		}
+		for _, name := range c.PropertiesChanged {
+			if u.Properties == nil {
+				u.Properties = make(map[string]string)
+			}
+			u.Properties[name] = v.Properties[name]
+		}
+	}
+	clear(s.initial)
+	if len(updates) > 0 {
+		msgs = append(msgs, &Message{Type: Update, Updates: updates})
+	}
+	return msgs, nil
+}
+
+// outgoingJSON returns the Value JSON sent for a variable: its wrapper if it has one.
+func outgoingJSON(v *changetracker.Variable) any {
+	if v.WrapperJSON != nil {
+		return v.WrapperJSON
+	}
+	return v.ValueJSON
+}
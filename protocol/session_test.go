@@ -0,0 +1,344 @@
+package protocol
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	changetracker "github.com/zot/change-tracker"
+)
+
+// ============================================================================
+// Session Tests (test-Session.md)
+// ============================================================================
+
+type Person struct {
+	Name string
+	Age  int
+}
+
+type Team struct {
+	Lead    *Person
+	Members []*Person
+}
+
+// client is the test side of a net.Pipe connection to a Session.
+type client struct {
+	t        *testing.T
+	enc      *Encoder
+	incoming chan *Message
+	tracker  *changetracker.SyncTracker
+	session  *Session
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+	return newClientFor(t, changetracker.NewSyncTracker(nil))
+}
+
+// newClientFor connects a new session for an existing tracker.
+func newClientFor(t *testing.T, tracker *changetracker.SyncTracker) *client {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	c := &client{
+		t:        t,
+		enc:      NewEncoder(clientConn),
+		incoming: make(chan *Message, 100),
+		tracker:  tracker,
+		session:  NewSession(tracker, serverConn, serverConn),
+	}
+	go c.session.Serve()
+	go func() {
+		dec := NewDecoder(clientConn)
+		for {
+			msg, err := dec.Decode()
+			if err != nil {
+				close(c.incoming)
+				return
+			}
+			c.incoming <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+	return c
+}
+
+func (c *client) send(msg *Message) {
+	c.t.Helper()
+	if err := c.enc.Encode(msg); err != nil {
+		c.t.Fatalf("send failed: %v", err)
+	}
+}
+
+func (c *client) receive() *Message {
+	c.t.Helper()
+	select {
+	case msg := <-c.incoming:
+		return msg
+	case <-time.After(time.Second):
+		c.t.Fatal("timed out waiting for message")
+		return nil
+	}
+}
+
+// waitFor waits until cond holds on the tracker, since the session handles
+// messages on its own goroutine.
+func (c *client) waitFor(cond func(*changetracker.Tracker) bool) {
+	c.t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		ok := false
+		c.tracker.Do(func(t *changetracker.Tracker) { ok = cond(t) })
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.t.Fatal("timed out waiting for condition")
+}
+
+func (c *client) sendUpdates() {
+	c.t.Helper()
+	done := make(chan error)
+	go func() { done <- c.session.SendUpdates() }()
+	if err := <-done; err != nil {
+		c.t.Fatalf("SendUpdates failed: %v", err)
+	}
+}
+
+func exists(id int64) func(*changetracker.Tracker) bool {
+	return func(t *changetracker.Tracker) bool { return t.GetVariable(id) != nil }
+}
+
+// P1.1: messages carry the protocol version
+func TestEncoder_Version(t *testing.T) {
+	c := newClient(t)
+	c.send(&Message{Type: Create, ID: 1, Value: json.RawMessage(`{"name":"x"}`)})
+	c.waitFor(exists(1))
+	c.sendUpdates()
+	msg := c.receive()
+	if msg.Version != Version {
+		t.Errorf("expected version %d, got %d", Version, msg.Version)
+	}
+}
+
+// P1.2: unsupported versions are rejected with an error message
+func TestSession_BadVersion(t *testing.T) {
+	c := newClient(t)
+	c.enc.enc.Encode(&Message{Version: 99, Type: Create, ID: 1})
+	msg := c.receive()
+	if msg.Type != Error || msg.ErrorType != ProtocolError {
+		t.Errorf("expected protocol error, got %+v", msg)
+	}
+}
+
+// P2.1: client create gets the initial state in the next update batch
+func TestSession_CreateInitialUpdate(t *testing.T) {
+	c := newClient(t)
+	data := &Team{Lead: &Person{Name: "Alice"}}
+	root := c.tracker.CreateVariable(data, 0, "", nil)
+	c.sendUpdates()
+	if msg := c.receive(); msg.Type != Create || msg.ID != root.ID {
+		t.Fatalf("expected create for root, got %+v", msg)
+	}
+
+	c.send(&Message{Type: Create, ID: 10, ParentID: root.ID, Path: "Lead.Name?label=Name"})
+	c.waitFor(exists(10))
+	c.sendUpdates()
+	msg := c.receive()
+	if msg.Type != Update || len(msg.Updates) != 1 {
+		t.Fatalf("expected one update, got %+v", msg)
+	}
+	u := msg.Updates[0]
+	if u.ID != 10 || string(u.Value) != `"Alice"` || u.Properties["label"] != "Name" {
+		t.Errorf("unexpected initial update %+v", u)
+	}
+}
+
+// P2.2: detected changes become update batches
+func TestSession_ChangeBatch(t *testing.T) {
+	c := newClient(t)
+	data := &Team{Lead: &Person{Name: "Alice", Age: 30}}
+	root := c.tracker.CreateVariable(data, 0, "", nil)
+	name := c.tracker.CreateVariable(nil, root.ID, "Lead.Name", nil)
+	age := c.tracker.CreateVariable(nil, root.ID, "Lead.Age", nil)
+	c.sendUpdates()
+	for range 3 {
+		if msg := c.receive(); msg.Type != Create {
+			t.Fatalf("expected create, got %+v", msg)
+		}
+	}
+
+	c.tracker.Do(func(*changetracker.Tracker) {
+		data.Lead.Name = "Bob"
+		data.Lead.Age = 31
+	})
+	c.tracker.SetProperty(age.ID, "label", "Age")
+	c.sendUpdates()
+	msg := c.receive()
+	if msg.Type != Update || len(msg.Updates) != 2 {
+		t.Fatalf("expected update with 2 variables, got %+v", msg)
+	}
+	got := make(map[int64]VariableUpdate)
+	for _, u := range msg.Updates {
+		got[u.ID] = u
+	}
+	if string(got[name.ID].Value) != `"Bob"` {
+		t.Errorf("expected name Bob, got %s", got[name.ID].Value)
+	}
+	if string(got[age.ID].Value) != "31" || got[age.ID].Properties["label"] != "Age" {
+		t.Errorf("expected age 31 with label, got %+v", got[age.ID])
+	}
+}
+
+// P2.3: set-value and set-property become tracker calls
+func TestSession_SetValueAndProperty(t *testing.T) {
+	c := newClient(t)
+	data := &Team{Lead: &Person{Name: "Alice"}}
+	root := c.tracker.CreateVariable(data, 0, "", nil)
+	name := c.tracker.CreateVariable(nil, root.ID, "Lead.Name", nil)
+
+	c.send(&Message{Type: SetValue, ID: name.ID, Value: json.RawMessage(`"Carol"`)})
+	c.send(&Message{Type: SetProperty, ID: name.ID, Properties: map[string]string{"hint": "x"}})
+	c.waitFor(func(t *changetracker.Tracker) bool {
+		return t.GetVariable(name.ID).GetProperty("hint") == "x"
+	})
+	c.tracker.Do(func(*changetracker.Tracker) {
+		if data.Lead.Name != "Carol" {
+			t.Errorf("expected Carol, got %s", data.Lead.Name)
+		}
+	})
+}
+
+// P2.4: destroy from client and from server
+func TestSession_Destroy(t *testing.T) {
+	c := newClient(t)
+	root := c.tracker.CreateVariable(&Team{Lead: &Person{}}, 0, "", nil)
+	a := c.tracker.CreateVariable(nil, root.ID, "Lead", nil)
+	name := c.tracker.CreateVariable(nil, a.ID, "Name", nil)
+	b := c.tracker.CreateVariable(nil, root.ID, "Members", nil)
+	c.sendUpdates()
+	for range 4 {
+		c.receive()
+	}
+
+	c.send(&Message{Type: Destroy, ID: a.ID})
+	c.waitFor(func(t *changetracker.Tracker) bool { return t.GetVariable(name.ID) == nil })
+	c.tracker.DestroyVariable(b.ID)
+	c.sendUpdates()
+	msg := c.receive()
+	// Only the server-side destroy is reported; the client already dropped its own subtree
+	if msg.Type != Destroy || msg.ID != b.ID {
+		t.Errorf("expected destroy of %d, got %+v", b.ID, msg)
+	}
+}
+
+// P2.5: failed requests produce typed error messages
+func TestSession_Errors(t *testing.T) {
+	c := newClient(t)
+	root := c.tracker.CreateVariable(&Team{}, 0, "", nil)
+
+	c.send(&Message{Type: SetValue, ID: 99, Value: json.RawMessage(`1`)})
+	if msg := c.receive(); msg.Type != Error || msg.ErrorType != "NotFound" || msg.ID != 99 {
+		t.Errorf("expected NotFound error, got %+v", msg)
+	}
+	c.send(&Message{Type: Create, ID: root.ID})
+	if msg := c.receive(); msg.Type != Error || msg.ErrorType != "IDConflict" {
+		t.Errorf("expected IDConflict error for duplicate ID, got %+v", msg)
+	}
+	c.send(&Message{Type: Create, ID: 5, ParentID: root.ID, Path: "SetX(_)?access=r"})
+	if msg := c.receive(); msg.Type != Error || msg.ErrorType != "BadAccessPath" {
+		t.Errorf("expected BadAccessPath error for invalid path, got %+v", msg)
+	}
+	c.send(&Message{Type: SetProperty, ID: root.ID, Properties: map[string]string{"access": "rx"}})
+	if msg := c.receive(); msg.Type != Error || msg.ErrorType != "BadAccessValue" {
+		t.Errorf("expected BadAccessValue error for invalid access, got %+v", msg)
+	}
+	c.send(&Message{Type: Update, ID: root.ID})
+	if msg := c.receive(); msg.Type != Error {
+		t.Errorf("expected error for unexpected message type, got %+v", msg)
+	}
+}
+
+// P2.6: array diffs are forwarded
+func TestSession_ArrayOps(t *testing.T) {
+	c := newClient(t)
+	alice, bob := &Person{Name: "Alice"}, &Person{Name: "Bob"}
+	data := &Team{Members: []*Person{alice}}
+	root := c.tracker.CreateVariable(data, 0, "", nil)
+	members := c.tracker.CreateVariable(nil, root.ID, "Members?diff=array", nil)
+	c.sendUpdates()
+	c.receive()
+	c.receive()
+
+	c.tracker.Do(func(*changetracker.Tracker) { data.Members = append(data.Members, bob) })
+	c.sendUpdates()
+	msg := c.receive()
+	if msg.Type != Update || len(msg.Updates) != 1 || msg.Updates[0].ID != members.ID {
+		t.Fatalf("expected update for members, got %+v", msg)
+	}
+	ops := msg.Updates[0].ArrayOps
+	if len(ops) != 1 || ops[0].Op != changetracker.ArrayInsert || ops[0].Index != 1 {
+		t.Errorf("expected one insert at 1, got %+v", ops)
+	}
+}
+
+// P2.7: sessions sharing a tracker each receive every change
+func TestSession_SharedTracker(t *testing.T) {
+	c1 := newClient(t)
+	c2 := newClientFor(t, c1.tracker)
+	data := &Team{Lead: &Person{Name: "Alice"}}
+	root := c1.tracker.CreateVariable(data, 0, "", nil)
+	name := c1.tracker.CreateVariable(nil, root.ID, "Lead.Name", nil)
+	for _, c := range []*client{c1, c2} {
+		c.sendUpdates()
+		c.receive()
+		c.receive()
+	}
+
+	c1.tracker.Do(func(*changetracker.Tracker) { data.Lead.Name = "Bob" })
+	c1.sendUpdates() // detects and reads c1's cursor only
+	c2.sendUpdates()
+	for i, c := range []*client{c1, c2} {
+		msg := c.receive()
+		if msg.Type != Update || len(msg.Updates) != 1 || msg.Updates[0].ID != name.ID || string(msg.Updates[0].Value) != `"Bob"` {
+			t.Errorf("session %d: expected name update, got %+v", i+1, msg)
+		}
+	}
+	c2.session.Close()
+	c1.tracker.Do(func(t2 *changetracker.Tracker) {
+		if changes, _ := c2.session.cursor.Read(); len(changes) != 0 {
+			t.Errorf("closed session should not retain changes, got %v", changes)
+		}
+	})
+}
+
+// P2.8: a move is sent as an update with the new parent
+func TestSession_Move(t *testing.T) {
+	c := newClient(t)
+	data := &Team{Lead: &Person{Name: "Alice"}, Members: []*Person{{Name: "Bob"}}}
+	root := c.tracker.CreateVariable(data, 0, "", nil)
+	lead := c.tracker.CreateVariable(nil, root.ID, "Lead", nil)
+	name := c.tracker.CreateVariable(nil, lead.ID, "Name", nil)
+	c.sendUpdates()
+	for range 3 {
+		c.receive()
+	}
+
+	if err := c.tracker.MoveVariable(name.ID, root.ID, "Members.0.Name"); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	c.sendUpdates()
+	msg := c.receive()
+	if msg.Type != Update || len(msg.Updates) != 1 {
+		t.Fatalf("expected one update, got %+v", msg)
+	}
+	u := msg.Updates[0]
+	if u.ParentID == nil || *u.ParentID != root.ID || string(u.Value) != `"Bob"` || u.Properties["path"] != "Members.0.Name" {
+		t.Errorf("expected move to %d with value and path, got %+v", root.ID, u)
+	}
+}
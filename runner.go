@@ -0,0 +1,96 @@
+// CRC: crc-Runner.md
+// Spec: api.md
+package changetracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Runner runs detection passes on a SyncTracker in a loop: one pass per
+// interval, plus passes requested with Trigger. Each pass runs
+// DetectChangesContext with Run's context and hands the GetChanges result to Hook, so the Runner should be the only
+// caller of GetChanges; other consumers can use cursors or subscriptions.
+// CRC: crc-Runner.md
+type Runner struct {
+	Hook  func([]Change) // receives each pass's changes, if any; may call SyncTracker methods
+	Clock Clock          // time source; nil uses the system clock
+
+	tracker  *SyncTracker
+	interval time.Duration
+	trigger  chan struct{}
+}
+
+// NewRunner creates a Runner for tracker that runs a pass every interval.
+// Panics if interval is not positive.
+func NewRunner(tracker *SyncTracker, interval time.Duration) *Runner {
+	if interval <= 0 {
+		panic(fmt.Sprintf("NewRunner: interval must be positive, got %v", interval))
+	}
+	return &Runner{tracker: tracker, interval: interval, trigger: make(chan struct{}, 1)}
+}
+
+// Trigger requests a pass without waiting for the interval. It never blocks.
+// The pass runs at once unless a triggered pass already ran within the last
+// interval; then the next timed pass covers it, so a burst of triggers causes
+// at most one extra pass per interval.
+func (r *Runner) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default: // already requested
+	}
+}
+
+// Run runs passes until ctx is done and returns ctx.Err(). Every pass restarts
+// the interval. Run must not be called concurrently with itself.
+// Sequence: seq-runner.md
+func (r *Runner) Run(ctx context.Context) error {
+	clock := clockOr(r.Clock)
+	var lastTriggered time.Time
+	for {
+		timer := clock.After(r.interval)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+		case <-r.trigger:
+			now := clock.Now()
+			if !lastTriggered.IsZero() && now.Sub(lastTriggered) < r.interval {
+				// Coalesce into the timed pass, which is due by lastTriggered+interval
+				if err := r.await(ctx, timer); err != nil {
+					return err
+				}
+				break
+			}
+			lastTriggered = now
+		}
+		r.pass(ctx)
+	}
+}
+
+// await waits for the pending timer, draining triggers that arrive meanwhile.
+func (r *Runner) await(ctx context.Context, timer <-chan time.Time) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+			return nil
+		case <-r.trigger:
+		}
+	}
+}
+
+// pass runs DetectChangesContext and hands the changes to Hook, so
+// cancelling ctx also cuts a slow pass short.
+func (r *Runner) pass(ctx context.Context) {
+	var changes []Change
+	r.tracker.Do(func(t *Tracker) {
+		t.DetectChangesContext(ctx)
+		changes = append([]Change(nil), t.GetChanges()...)
+	})
+	if len(changes) > 0 && r.Hook != nil {
+		r.Hook(changes)
+	}
+}
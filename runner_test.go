@@ -0,0 +1,146 @@
+package changetracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Runner Tests (test-Runner.md)
+// ============================================================================
+
+// waitFor waits for the runner goroutine to reach a state.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// nextHook waits for the hook and checks that it got a change for id.
+func nextHook(t *testing.T, hooked chan []Change, id int64) {
+	t.Helper()
+	select {
+	case changes := <-hooked:
+		if len(changes) != 1 || changes[0].VariableID != id {
+			t.Errorf("expected the change for %d, got %v", id, changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the hook")
+	}
+}
+
+func noHook(t *testing.T, hooked chan []Change) {
+	t.Helper()
+	select {
+	case changes := <-hooked:
+		t.Errorf("expected no hook call, got %v", changes)
+	default:
+	}
+}
+
+// RN1.1: a pass runs every interval and hands its changes to the hook
+func TestRunner_Interval(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+	s.GetChanges()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hooked := make(chan []Change, 10)
+	runner := NewRunner(s, time.Second)
+	runner.Clock = clock
+	runner.Hook = func(changes []Change) { hooked <- changes }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	waitFor(t, "the first timer", func() bool { return clock.timers() == 1 })
+
+	s.Do(func(*Tracker) { p.Name = "Bob" })
+	clock.advance(time.Second)
+	nextHook(t, hooked, name.ID)
+	if len(s.GetChanges()) != 0 {
+		t.Error("expected the runner to consume the changes")
+	}
+
+	// A pass without changes does not call the hook
+	waitFor(t, "the second timer", func() bool { return clock.timers() == 2 })
+	clock.advance(time.Second)
+	waitFor(t, "the third timer", func() bool { return clock.timers() == 3 })
+	noHook(t, hooked)
+}
+
+// RN1.2: triggers run a pass at once, at most once per interval
+func TestRunner_Trigger(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+	s.GetChanges()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hooked := make(chan []Change, 10)
+	runner := NewRunner(s, time.Second)
+	runner.Clock = clock
+	runner.Hook = func(changes []Change) { hooked <- changes }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	waitFor(t, "the first timer", func() bool { return clock.timers() == 1 })
+
+	s.Do(func(*Tracker) { p.Name = "Bob" })
+	runner.Trigger()
+	nextHook(t, hooked, name.ID)
+
+	// A burst within the interval waits for the timed pass
+	waitFor(t, "the second timer", func() bool { return clock.timers() == 2 })
+	s.Do(func(*Tracker) { p.Name = "Carol" })
+	for range 3 {
+		runner.Trigger()
+	}
+	waitFor(t, "the triggers to be taken", func() bool { return len(runner.trigger) == 0 })
+	noHook(t, hooked)
+	clock.advance(time.Second)
+	nextHook(t, hooked, name.ID)
+	waitFor(t, "the third timer", func() bool { return clock.timers() == 3 })
+	noHook(t, hooked)
+
+	// A trigger after the interval runs at once again
+	s.Do(func(*Tracker) { p.Name = "Dave" })
+	runner.Trigger()
+	nextHook(t, hooked, name.ID)
+}
+
+// RN1.3: Run stops when its context is done; intervals must be positive
+func TestRunner_Stop(t *testing.T) {
+	s := NewSyncTracker(nil)
+	runner := NewRunner(s, time.Second)
+	runner.Clock = &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a zero interval")
+		}
+	}()
+	NewRunner(s, 0)
+}
@@ -0,0 +1,162 @@
+// CRC: crc-Snapshot.md
+// Spec: api.md
+package changetracker
+
+import (
+	"maps"
+	"slices"
+)
+
+// Snapshot describes every variable of a tracker. It can be serialized to
+// JSON and later restored against fresh root values.
+// CRC: crc-Snapshot.md
+type Snapshot struct {
+	Variables []VariableSnapshot `json:"variables"` // parents before children
+}
+
+// VariableSnapshot describes one variable.
+type VariableSnapshot struct {
+	ID          int64               `json:"id"`
+	ParentID    int64               `json:"parentId,omitempty"`
+	Path        string              `json:"path,omitempty"`
+	Properties  map[string]string   `json:"properties,omitempty"` // including path and access
+	Priorities  map[string]Priority `json:"priorities,omitempty"` // property priorities
+	Access      string              `json:"access"`
+	Active      bool                `json:"active"`
+	ValueJSON   any                 `json:"value,omitempty"`
+	WrapperJSON any                 `json:"wrapper,omitempty"`
+}
+
+// Snapshot returns a description of every variable, parents before children
+// and children in ChildIDs order.
+// Sequence: seq-snapshot.md
+func (t *Tracker) Snapshot() *Snapshot {
+	s := &Snapshot{Variables: make([]VariableSnapshot, 0, len(t.variables))}
+	var add func(id int64)
+	add = func(id int64) {
+		v := t.variables[id]
+		if v == nil {
+			return
+		}
+		s.Variables = append(s.Variables, VariableSnapshot{
+			ID:          v.ID,
+			ParentID:    v.ParentID,
+			Path:        v.Properties["path"],
+			Properties:  maps.Clone(v.Properties),
+			Priorities:  maps.Clone(v.PropertyPriorities),
+			Access:      v.Access,
+			Active:      v.Active,
+			ValueJSON:   v.ValueJSON,
+			WrapperJSON: v.WrapperJSON,
+		})
+		for _, childID := range v.ChildIDs {
+			add(childID)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(t.rootIDs)) {
+		add(id)
+	}
+	return s
+}
+
+// Restore recreates the variables of a snapshot, with their IDs, taking root
+// values from roots (missing roots get nil). Child values are resolved afresh.
+// Returns the IDs of the variables whose value now differs from the snapshot's
+// ValueJSON or cannot be resolved. Object references count as equal to each
+// other, since object IDs do not survive a restart.
+// Fails with IDConflict if a snapshot ID is in use, or with the error of a
+// variable that cannot be created, such as BadAccessValue for an invalid Access;
+// the tracker's variables, changes, and versions are unchanged on error.
+// Sequence: seq-snapshot.md
+func (t *Tracker) Restore(s *Snapshot, roots map[int64]any) ([]int64, error) {
+	reserved := make(map[int64]bool, len(s.Variables))
+	for _, vs := range s.Variables {
+		if t.idInUse(vs.ID) || reserved[vs.ID] {
+			return nil, verror(IDConflict, "ID %d is already in use", vs.ID)
+		}
+		reserved[vs.ID] = true
+	}
+	// Keep objects registered while restoring from taking snapshot IDs
+	t.reserved = reserved
+	defer func() { t.reserved = nil }()
+	seq := t.seq
+	destroyed := make(map[int64]int64)
+	for id := range reserved {
+		if version, ok := t.destroyed[id]; ok {
+			destroyed[id] = version
+		}
+	}
+	var restored []int64
+	var differ []int64
+	for _, vs := range s.Variables {
+		var value any
+		if vs.ParentID == 0 {
+			value = roots[vs.ID]
+		}
+		props := maps.Clone(vs.Properties)
+		if props == nil {
+			props = make(map[string]string)
+		}
+		if vs.Path != "" {
+			props["path"] = vs.Path
+		}
+		// Access wins over the access property and is validated like it
+		if vs.Access != "" && (vs.Access != "rw" || props["access"] != "") {
+			props["access"] = vs.Access
+		}
+		v, err := t.TryCreateVariableWithId(vs.ID, value, vs.ParentID, "", props)
+		if err != nil {
+			// Remove without versioning, so Restore leaves no trace
+			for _, id := range slices.Backward(restored) {
+				t.removeVariable(id)
+			}
+			t.seq = seq
+			maps.Copy(t.destroyed, destroyed)
+			return nil, err
+		}
+		restored = append(restored, v.ID)
+		delete(reserved, v.ID)
+		maps.Copy(v.PropertyPriorities, vs.Priorities)
+		v.Active = vs.Active
+		if v.IsReadable() && (v.Error != nil || !sameResolution(vs.ValueJSON, v.ValueJSON)) {
+			differ = append(differ, v.ID)
+		}
+	}
+	return differ, nil
+}
+
+// sameResolution compares Value JSON from a snapshot, possibly decoded from
+// JSON, with fresh Value JSON. Object references are equal to each other.
+func sameResolution(old, cur any) bool {
+	if isRefJSON(old) || isRefJSON(cur) {
+		return isRefJSON(old) && isRefJSON(cur)
+	}
+	oldArr, ok1 := old.([]any)
+	curArr, ok2 := cur.([]any)
+	if ok1 && ok2 {
+		if len(oldArr) != len(curArr) || (oldArr == nil) != (curArr == nil) {
+			return false
+		}
+		for i := range oldArr {
+			if !sameResolution(oldArr[i], curArr[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return jsonEqual(old, cur)
+}
+
+// isRefJSON reports whether v is an object reference, either as an ObjectRef
+// or as its decoded JSON form {"obj": n}.
+func isRefJSON(v any) bool {
+	if IsObjectRef(v) {
+		return true
+	}
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return false
+	}
+	_, ok = m["obj"].(float64)
+	return ok
+}
@@ -0,0 +1,166 @@
+package changetracker
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// ============================================================================
+// Snapshot Tests (test-Snapshot.md)
+// ============================================================================
+
+// snapshotTree builds a tracker over p and returns the variables by name.
+func snapshotTree(p *Person) (*Tracker, map[string]*Variable) {
+	tr := NewTracker()
+	vars := map[string]*Variable{}
+	vars["root"] = tr.CreateVariable(p, 0, "", nil)
+	vars["name"] = tr.CreateVariable(nil, vars["root"].ID, "Name?priority=high", map[string]string{"label": "Name"})
+	vars["name"].SetProperty("hint:low", "first name")
+	vars["address"] = tr.CreateVariable(nil, vars["root"].ID, "Address", nil)
+	vars["city"] = tr.CreateVariable(nil, vars["address"].ID, "City?access=r", nil)
+	vars["tags"] = tr.CreateVariable(nil, vars["root"].ID, "Tags", nil)
+	vars["tags"].SetActive(false)
+	return tr, vars
+}
+
+// roundTrip serializes a snapshot to JSON and back.
+func roundTrip(t *testing.T, s *Snapshot) *Snapshot {
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result Snapshot
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return &result
+}
+
+func newSnapshotPerson() *Person {
+	return &Person{Name: "Alice", Address: &Address{City: "Paris"}, Tags: []string{"a", "b"}}
+}
+
+// SN1.1: snapshots survive JSON and restore the same tree
+func TestSnapshot_RoundTrip(t *testing.T) {
+	tr, vars := snapshotTree(newSnapshotPerson())
+	snap := roundTrip(t, tr.Snapshot())
+	if len(snap.Variables) != 5 || snap.Variables[0].ID != vars["root"].ID {
+		t.Fatalf("expected 5 variables, root first, got %+v", snap.Variables)
+	}
+
+	tr2 := NewTracker()
+	differ, err := tr2.Restore(snap, map[int64]any{vars["root"].ID: newSnapshotPerson()})
+	if err != nil || len(differ) != 0 {
+		t.Fatalf("expected identical restore, got %v (%v)", differ, err)
+	}
+	for key, v := range vars {
+		r := tr2.GetVariable(v.ID)
+		if r == nil || r.ParentID != v.ParentID || !slices.Equal(r.ChildIDs, v.ChildIDs) ||
+			!maps.Equal(r.Properties, v.Properties) || !maps.Equal(r.PropertyPriorities, v.PropertyPriorities) ||
+			r.Access != v.Access || r.Active != v.Active || r.ValuePriority != v.ValuePriority {
+			t.Errorf("%s: restored %+v, want %+v", key, r, v)
+		}
+	}
+	if v := tr2.CreateVariable(nil, vars["root"].ID, "Age", nil); v == nil || len(tr2.Variables()) != 6 {
+		t.Errorf("expected a new variable with an unused ID, got %v", v)
+	}
+	if tr2.DetectChanges() {
+		t.Error("expected restored caches to be current")
+	}
+}
+
+// SN1.2: Restore reports variables that resolve differently
+func TestSnapshot_Differences(t *testing.T) {
+	tr, vars := snapshotTree(newSnapshotPerson())
+	snap := roundTrip(t, tr.Snapshot())
+	p := &Person{Name: "Bob", Address: &Address{}, Tags: []string{"a", "c"}}
+	differ, err := NewTracker().Restore(snap, map[int64]any{vars["root"].ID: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Address is a different object, but object references are not compared
+	want := []int64{vars["name"].ID, vars["city"].ID, vars["tags"].ID}
+	if !slices.Equal(differ, want) {
+		t.Errorf("expected %v, got %v", want, differ)
+	}
+	differ, _ = NewTracker().Restore(snap, nil)
+	if len(differ) != 5 {
+		t.Errorf("expected everything readable to differ without roots, got %v", differ)
+	}
+}
+
+// SN1.3: conflicting IDs leave the tracker unchanged
+func TestSnapshot_Conflict(t *testing.T) {
+	tr, vars := snapshotTree(newSnapshotPerson())
+	snap := tr.Snapshot()
+	tr2 := NewTracker()
+	tr2.CreateVariableWithId(vars["city"].ID, 1, 0, "", nil)
+	if _, err := tr2.Restore(snap, nil); errorType(err) != IDConflict {
+		t.Errorf("expected IDConflict, got %v", err)
+	}
+	if len(tr2.Variables()) != 1 {
+		t.Errorf("expected tracker unchanged, got %d variables", len(tr2.Variables()))
+	}
+}
+
+// SN1.4: objects registered during restore do not take snapshot IDs
+func TestSnapshot_ReservedIDs(t *testing.T) {
+	tr := NewTracker()
+	p := newSnapshotPerson()
+	root := tr.CreateVariable(p, 0, "", nil)
+	tr.CreateVariable(nil, root.ID, "Address", nil)
+	snap := &Snapshot{Variables: []VariableSnapshot{
+		{ID: 1, Active: true},
+		{ID: 2, ParentID: 1, Path: "Address", Active: true},
+	}}
+	tr2 := NewTracker()
+	if _, err := tr2.Restore(snap, map[int64]any{1: p}); err != nil {
+		t.Fatalf("expected restore to succeed, got %v", err)
+	}
+	if id, _ := tr2.LookupObject(p); id == 1 || id == 2 {
+		t.Errorf("object took reserved ID %d", id)
+	}
+}
+
+// SN1.5: SyncTracker snapshots
+func TestSyncTracker_Snapshot(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	s.CreateVariable(nil, root.ID, "Name", nil)
+	s2 := NewSyncTracker(nil)
+	differ, err := s2.Restore(s.Snapshot(), map[int64]any{root.ID: &Person{Name: "Bob"}})
+	if err != nil || len(differ) != 1 {
+		t.Errorf("expected one difference, got %v (%v)", differ, err)
+	}
+}
+
+// SN1.6: invalid access fails and rolls back without a trace
+func TestSnapshot_Rollback(t *testing.T) {
+	tr, vars := snapshotTree(newSnapshotPerson())
+	snap := tr.Snapshot()
+	for i := range snap.Variables {
+		if snap.Variables[i].ID == vars["tags"].ID {
+			snap.Variables[i].Access = "bogus"
+		}
+	}
+	tr2 := NewTracker()
+	tr2.CreateVariableWithId(100, 1, 0, "", nil)
+	tr2.GetChanges()
+	checkpoint := tr2.NewCheckpoint()
+	seq := tr2.Seq()
+	if _, err := tr2.Restore(snap, map[int64]any{vars["root"].ID: newSnapshotPerson()}); errorType(err) != BadAccessValue {
+		t.Errorf("expected BadAccessValue, got %v", err)
+	}
+	if len(tr2.Variables()) != 1 || tr2.Seq() != seq || len(tr2.GetChanges()) != 0 {
+		t.Errorf("expected tracker unchanged, got %d variables, seq %d", len(tr2.Variables()), tr2.Seq())
+	}
+	if changes := checkpoint.Changes(); len(changes) != 0 {
+		t.Errorf("expected no versioned changes, got %+v", changes)
+	}
+	if _, err := tr2.Restore(tr.Snapshot(), nil); err != nil {
+		t.Errorf("expected the IDs to be free again, got %v", err)
+	}
+}
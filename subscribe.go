@@ -0,0 +1,207 @@
+// CRC: crc-Subscription.md
+// Spec: api.md
+package changetracker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ChangeFilter selects the changes a subscription receives.
+// It returns the change to deliver, possibly trimmed, and false to skip it.
+// CRC: crc-Subscription.md
+type ChangeFilter func(t *Tracker, c Change) (Change, bool)
+
+// SubtreeFilter passes changes to rootID and its descendants.
+func SubtreeFilter(rootID int64) ChangeFilter {
+	return func(t *Tracker, c Change) (Change, bool) {
+		for v := t.variables[c.VariableID]; v != nil; v = t.variables[v.ParentID] {
+			if v.ID == rootID {
+				return c, true
+			}
+		}
+		return c, false
+	}
+}
+
+// PropertyFilter passes changes to the named properties, without value changes.
+func PropertyFilter(names ...string) ChangeFilter {
+	return func(t *Tracker, c Change) (Change, bool) {
+		var props []string
+		for _, name := range c.PropertiesChanged {
+			if slices.Contains(names, name) {
+				props = append(props, name)
+			}
+		}
+		if len(props) == 0 {
+			return c, false
+		}
+		trimmed := Change{
+			VariableID:        c.VariableID,
+			Priority:          c.Priority,
+			PropertiesChanged: props,
+		}
+		if c.OldProperties != nil {
+			trimmed.OldProperties = make(map[string]string, len(props))
+			trimmed.NewProperties = make(map[string]string, len(props))
+			for _, name := range props {
+				trimmed.OldProperties[name] = c.OldProperties[name]
+				trimmed.NewProperties[name] = c.NewProperties[name]
+			}
+		}
+		return trimmed, true
+	}
+}
+
+// PriorityFilter passes changes at priority min or higher.
+func PriorityFilter(min Priority) ChangeFilter {
+	return func(t *Tracker, c Change) (Change, bool) {
+		return c, c.Priority >= min
+	}
+}
+
+// AllFilters passes changes that pass every filter, applying them in order.
+func AllFilters(filters ...ChangeFilter) ChangeFilter {
+	return func(t *Tracker, c Change) (Change, bool) {
+		for _, f := range filters {
+			var ok bool
+			if c, ok = f(t, c); !ok {
+				return c, false
+			}
+		}
+		return c, true
+	}
+}
+
+// Subscription delivers the changes of each detection cycle to one consumer.
+// CRC: crc-Subscription.md
+type Subscription struct {
+	filter    ChangeFilter
+	fn        func([]Change)
+	cancelled atomic.Bool
+}
+
+// Cancel stops deliveries. It is safe to call from any goroutine, including
+// from the subscription's own callback.
+func (s *Subscription) Cancel() {
+	s.cancelled.Store(true)
+}
+
+// Subscribe calls fn after each DetectChanges with the changes of that cycle
+// that pass filter (nil passes everything). Property changes made between
+// cycles are delivered with the next cycle. fn is not called when no changes pass.
+// Sequence: seq-subscription.md
+func (t *Tracker) Subscribe(filter ChangeFilter, fn func([]Change)) *Subscription {
+	s := &Subscription{filter: filter, fn: fn}
+	t.subscriptions = append(t.subscriptions, s)
+	if t.cycle == nil {
+		t.cycle = newChangeRecord()
+	}
+	return s
+}
+
+// Changes returns a channel that receives the changes of each detection cycle
+// that pass all filters. Batches queue up until they are received, so detection
+// never blocks on the channel. The channel is closed when ctx is done.
+// Sequence: seq-subscription.md
+func (t *Tracker) Changes(ctx context.Context, filters ...ChangeFilter) <-chan []Change {
+	out := make(chan []Change)
+	q := &changeQueue{wake: make(chan struct{}, 1)}
+	var filter ChangeFilter
+	if len(filters) > 0 {
+		filter = AllFilters(filters...)
+	}
+	s := t.Subscribe(filter, q.push)
+	go q.run(ctx, s, out)
+	return out
+}
+
+// notify delivers the current cycle's changes to the subscriptions.
+// Sequence: seq-subscription.md
+func (t *Tracker) notify() {
+	if t.cycle == nil {
+		return
+	}
+	t.subscriptions = slices.DeleteFunc(t.subscriptions, func(s *Subscription) bool {
+		return s.cancelled.Load()
+	})
+	if len(t.subscriptions) == 0 {
+		t.cycle = nil
+		return
+	}
+	batch := t.buildChanges(nil, *t.cycle, t.ChangeDetails)
+	t.cycle = newChangeRecord()
+	if len(batch) == 0 {
+		return
+	}
+	// Callbacks may subscribe or cancel
+	for _, s := range slices.Clone(t.subscriptions) {
+		if s.cancelled.Load() {
+			continue
+		}
+		if changes := t.filterChanges(batch, s.filter); len(changes) > 0 {
+			s.fn(changes)
+		}
+	}
+}
+
+// filterChanges returns a new slice with the changes that pass filter.
+func (t *Tracker) filterChanges(batch []Change, filter ChangeFilter) []Change {
+	result := make([]Change, 0, len(batch))
+	for _, c := range batch {
+		if filter != nil {
+			var ok bool
+			if c, ok = filter(t, c); !ok {
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// changeQueue buffers batches for a Changes channel.
+type changeQueue struct {
+	mu      sync.Mutex
+	batches [][]Change
+	wake    chan struct{}
+}
+
+// push queues a batch; called by the tracker during DetectChanges.
+func (q *changeQueue) push(changes []Change) {
+	q.mu.Lock()
+	q.batches = append(q.batches, changes)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends queued batches to out until ctx is done, then cancels s and closes out.
+func (q *changeQueue) run(ctx context.Context, s *Subscription, out chan<- []Change) {
+	defer close(out)
+	defer s.Cancel()
+	for {
+		q.mu.Lock()
+		if len(q.batches) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+				continue
+			}
+		}
+		batch := q.batches[0]
+		q.batches = q.batches[1:]
+		q.mu.Unlock()
+		select {
+		case out <- batch:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
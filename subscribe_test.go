@@ -0,0 +1,184 @@
+package changetracker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// Subscription Tests (test-Subscription.md)
+// ============================================================================
+
+// SB1.1: subscribers receive each cycle's changes, independent of GetChanges
+func TestSubscribe_Cycles(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	var batches [][]Change
+	tr.Subscribe(nil, func(changes []Change) { batches = append(batches, changes) })
+
+	people[0].Name = "Ann"
+	tr.DetectChanges()
+	tr.DetectChanges() // no changes: no call
+	people[1].Name = "Ben"
+	tr.DetectChanges()
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %v", batches)
+	}
+	if ids := changeIDs(batches[0]); len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("expected first batch for %d, got %v", aName.ID, batches[0])
+	}
+	if ids := changeIDs(batches[1]); len(ids) != 1 || !ids[bName.ID] {
+		t.Errorf("expected second batch for %d, got %v", bName.ID, batches[1])
+	}
+	if changes := tr.GetChanges(); len(changeIDs(changes)) != 2 {
+		t.Errorf("GetChanges should still report both changes, got %v", changes)
+	}
+}
+
+// SB1.2: subtree, property and priority filters
+func TestSubscribe_Filters(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	var subtree, props, high, both []Change
+	tr.Subscribe(SubtreeFilter(a.ID), func(c []Change) { subtree = append(subtree, c...) })
+	tr.Subscribe(PropertyFilter("label"), func(c []Change) { props = append(props, c...) })
+	tr.Subscribe(PriorityFilter(PriorityHigh), func(c []Change) { high = append(high, c...) })
+	tr.Subscribe(AllFilters(SubtreeFilter(b.ID), PropertyFilter("label")), func(c []Change) { both = append(both, c...) })
+
+	people[0].Name = "Ann"
+	people[1].Name = "Ben"
+	aName.SetProperty("label", "first")
+	bName.SetProperty("label", "second")
+	bName.SetProperty("hint", "x")
+	tr.DetectChanges()
+
+	if ids := changeIDs(subtree); len(ids) != 1 || !ids[aName.ID] {
+		t.Errorf("subtree: expected only %d, got %v", aName.ID, subtree)
+	}
+	for _, c := range props {
+		if c.ValueChanged || len(c.PropertiesChanged) != 1 || c.PropertiesChanged[0] != "label" {
+			t.Errorf("property filter: expected label-only changes, got %+v", c)
+		}
+	}
+	if len(changeIDs(props)) != 2 {
+		t.Errorf("property filter: expected 2 variables, got %v", props)
+	}
+	for _, c := range high {
+		if c.Priority != PriorityHigh {
+			t.Errorf("priority filter: got %+v", c)
+		}
+	}
+	if len(high) != 1 || high[0].VariableID != bName.ID || !high[0].ValueChanged {
+		t.Errorf("priority filter: expected high value change for %d, got %v", bName.ID, high)
+	}
+	if len(both) != 1 || both[0].VariableID != bName.ID {
+		t.Errorf("combined filter: expected label change for %d, got %v", bName.ID, both)
+	}
+}
+
+// SB1.3: cancelled subscriptions stop receiving changes
+func TestSubscribe_Cancel(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	calls := 0
+	var s *Subscription
+	s = tr.Subscribe(nil, func([]Change) {
+		calls++
+		s.Cancel()
+	})
+	for _, name := range []string{"A", "B"} {
+		people[0].Name = name
+		tr.DetectChanges()
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+	if len(tr.subscriptions) != 0 || tr.cycle != nil {
+		t.Error("expected cancelled subscription to be removed")
+	}
+}
+
+// SB1.4: ChangeDetails apply per cycle
+func TestSubscribe_Details(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	tr.ChangeDetails = true
+	var last []Change
+	tr.Subscribe(nil, func(c []Change) { last = c })
+	people[0].Name = "Ann"
+	tr.DetectChanges()
+	people[0].Name = "Amy"
+	tr.DetectChanges()
+	if len(last) != 1 || last[0].OldValueJSON != "Ann" || last[0].NewValueJSON != "Amy" {
+		t.Errorf("expected Ann -> Amy for the second cycle, got %+v", last)
+	}
+}
+
+// SB2.1: Changes delivers batches on a channel and closes it when ctx is done
+func TestChanges_Channel(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := s.Changes(ctx, SubtreeFilter(root.ID))
+
+	for _, n := range []string{"Bob", "Carol"} {
+		s.Do(func(*Tracker) { p.Name = n })
+		s.DetectChanges() // does not block on the unread channel
+	}
+	for range 2 {
+		select {
+		case batch := <-ch:
+			if len(batch) != 1 || batch[0].VariableID != name.ID {
+				t.Errorf("expected change for %d, got %v", name.ID, batch)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for changes")
+		}
+	}
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expected channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for close")
+	}
+	s.DetectChanges()
+	s.Do(func(t2 *Tracker) {
+		if len(t2.subscriptions) != 0 {
+			t.Error("expected channel subscription to be removed")
+		}
+	})
+}
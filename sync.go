@@ -0,0 +1,407 @@
+// CRC: crc-SyncTracker.md
+// Spec: api.md
+package changetracker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SyncTracker wraps a Tracker so it can be used from multiple goroutines.
+// Every method acquires the same mutex, so variable creation, destruction,
+// property changes, value sets, registry calls and change detection are serialized.
+// Variables returned by SyncTracker must only be used inside Do; use the
+// ID-based methods on SyncTracker everywhere else.
+// CRC: crc-SyncTracker.md
+type SyncTracker struct {
+	mu      sync.Mutex
+	tracker *Tracker
+}
+
+// NewSyncTracker wraps t for concurrent use. If t is nil, a new Tracker is created.
+// The caller must not use t directly after wrapping it.
+func NewSyncTracker(t *Tracker) *SyncTracker {
+	if t == nil {
+		t = NewTracker()
+	}
+	return &SyncTracker{tracker: t}
+}
+
+// Do runs f while holding the tracker's lock.
+// Use it to mutate domain data and detect changes atomically. f must not call
+// methods on this SyncTracker, since the lock is not reentrant.
+func (s *SyncTracker) Do(f func(*Tracker)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.tracker)
+}
+
+// CreateVariable creates a new variable with an auto-assigned ID.
+func (s *SyncTracker) CreateVariable(value any, parentID int64, path string, properties map[string]string) *Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.CreateVariable(value, parentID, path, properties)
+}
+
+// CreateVariableWithId creates a new variable with a caller-specified ID.
+// Returns nil if the ID is already in use.
+func (s *SyncTracker) CreateVariableWithId(id int64, value any, parentID int64, path string, properties map[string]string) *Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.CreateVariableWithId(id, value, parentID, path, properties)
+}
+
+// TryCreateVariable creates a new variable with an auto-assigned ID,
+// returning a *VariableError for invalid input.
+func (s *SyncTracker) TryCreateVariable(value any, parentID int64, path string, properties map[string]string) (*Variable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.TryCreateVariable(value, parentID, path, properties)
+}
+
+// TryCreateVariableWithId creates a new variable with a caller-specified ID,
+// returning a *VariableError for invalid input or an ID in use.
+func (s *SyncTracker) TryCreateVariableWithId(id int64, value any, parentID int64, path string, properties map[string]string) (*Variable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.TryCreateVariableWithId(id, value, parentID, path, properties)
+}
+
+// DestroyVariable removes a variable (and its descendants unless OrphanChildren is set).
+func (s *SyncTracker) DestroyVariable(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracker.DestroyVariable(id)
+}
+
+// DestroySubtree removes a variable and its descendants and returns the destroyed IDs.
+func (s *SyncTracker) DestroySubtree(id int64) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.DestroySubtree(id)
+}
+
+// MoveVariable moves a variable under another parent; see Tracker.MoveVariable.
+func (s *SyncTracker) MoveVariable(id, newParentID int64, newPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.MoveVariable(id, newParentID, newPath)
+}
+
+// Get returns the variable's current value, checking access.
+func (s *SyncTracker) Get(id int64) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return nil, verror(NotFound, "variable %d not found", id)
+	}
+	return v.Get()
+}
+
+// Set sets the variable's value, checking access.
+func (s *SyncTracker) Set(id int64, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return verror(NotFound, "variable %d not found", id)
+	}
+	return v.Set(value)
+}
+
+// SetIfUnchanged sets the variable's value if its current Value JSON equals expected.
+// Returns a Conflict error otherwise; see Variable.SetIfUnchanged.
+func (s *SyncTracker) SetIfUnchanged(id int64, expected any, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return verror(NotFound, "variable %d not found", id)
+	}
+	return v.SetIfUnchanged(expected, value)
+}
+
+// SetIfVersion sets the variable's value if it is still at version.
+// Returns a Conflict error otherwise; see Variable.SetIfVersion.
+func (s *SyncTracker) SetIfVersion(id int64, version int64, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return verror(NotFound, "variable %d not found", id)
+	}
+	return v.SetIfVersion(version, value)
+}
+
+// Transaction runs a transaction under the lock; see Tracker.Transaction.
+// fn must not call SyncTracker methods.
+func (s *SyncTracker) Transaction(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.Transaction(fn)
+}
+
+// GetProperty returns a property of a variable, or empty string if either is missing.
+func (s *SyncTracker) GetProperty(id int64, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return ""
+	}
+	return v.GetProperty(name)
+}
+
+// SetProperty sets a property on a variable. Empty value removes the property.
+// Invalid paths and access values are returned as errors.
+func (s *SyncTracker) SetProperty(id int64, name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return verror(NotFound, "variable %d not found", id)
+	}
+	return v.TrySetProperty(name, value)
+}
+
+// SetActive sets whether a variable and its children are checked for changes.
+func (s *SyncTracker) SetActive(id int64, active bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.tracker.GetVariable(id)
+	if v == nil {
+		return verror(NotFound, "variable %d not found", id)
+	}
+	v.SetActive(active)
+	return nil
+}
+
+// DetectChanges runs change detection on the wrapped tracker.
+func (s *SyncTracker) DetectChanges() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.DetectChanges()
+}
+
+// DetectChangesContext detects changes with a context; see Tracker.DetectChangesContext.
+// The lock is held for the whole pass.
+func (s *SyncTracker) DetectChangesContext(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.DetectChangesContext(ctx)
+}
+
+// DetectChangesBudget runs part of a detection pass; see Tracker.DetectChangesBudget.
+// The lock is held for the whole call.
+func (s *SyncTracker) DetectChangesBudget(ctx context.Context, maxDuration time.Duration) (changed, complete bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.DetectChangesBudget(ctx, maxDuration)
+}
+
+// Subscribe registers fn for the changes of each detection cycle.
+// fn runs during DetectChanges with the lock held, so it must not call SyncTracker methods.
+func (s *SyncTracker) Subscribe(filter ChangeFilter, fn func([]Change)) *Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.Subscribe(filter, fn)
+}
+
+// Changes returns a channel that receives the changes of each detection cycle.
+// Receivers may call SyncTracker methods.
+func (s *SyncTracker) Changes(ctx context.Context, filters ...ChangeFilter) <-chan []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.Changes(ctx, filters...)
+}
+
+// NewCursor registers an independent change consumer.
+// Read it with ReadCursor and close it with CloseCursor.
+func (s *SyncTracker) NewCursor(max int) *Cursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.NewCursor(max)
+}
+
+// ReadCursor returns the cursor's changes since its last read; see Cursor.Read.
+// The result is owned by the caller.
+func (s *SyncTracker) ReadCursor(c *Cursor) ([]Change, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.Read()
+}
+
+// CloseCursor unregisters a cursor.
+func (s *SyncTracker) CloseCursor(c *Cursor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Close()
+}
+
+// NewHistory starts recording undo history; see Tracker.NewHistory.
+// Use Undo and Redo, or Do for other History methods.
+func (s *SyncTracker) NewHistory(depth int) *History {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.NewHistory(depth)
+}
+
+// Undo undoes the most recent step of h.
+func (s *SyncTracker) Undo(h *History) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return h.Undo()
+}
+
+// Redo redoes the most recently undone step of h.
+func (s *SyncTracker) Redo(h *History) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return h.Redo()
+}
+
+// Snapshot describes every variable; see Tracker.Snapshot.
+func (s *SyncTracker) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.Snapshot()
+}
+
+// Restore recreates the variables of a snapshot; see Tracker.Restore.
+func (s *SyncTracker) Restore(snap *Snapshot, roots map[int64]any) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.Restore(snap, roots)
+}
+
+// Seq returns the tracker's change sequence number.
+func (s *SyncTracker) Seq() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.Seq()
+}
+
+// ChangesSince returns the changes made after seq; see Tracker.ChangesSince.
+func (s *SyncTracker) ChangesSince(seq int64) []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.ChangesSince(seq)
+}
+
+// NewCheckpoint registers a version consumer at the current sequence number.
+// Read it with CheckpointChanges and close it with CloseCheckpoint.
+func (s *SyncTracker) NewCheckpoint() *Checkpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.NewCheckpoint()
+}
+
+// CheckpointChanges returns the changes since the checkpoint and moves it to
+// the current sequence number; see Checkpoint.Changes.
+func (s *SyncTracker) CheckpointChanges(c *Checkpoint) []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.Changes()
+}
+
+// CloseCheckpoint unregisters a checkpoint.
+func (s *SyncTracker) CloseCheckpoint(c *Checkpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Close()
+}
+
+// VariablesChangedSince returns the IDs of the variables created or changed
+// after seq, in the order of their last change.
+func (s *SyncTracker) VariablesChangedSince(seq int64) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vars := s.tracker.VariablesChangedSince(seq)
+	ids := make([]int64, len(vars))
+	for i, v := range vars {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+// MarkDirty marks a registered object as changed for DetectDirty.
+func (s *SyncTracker) MarkDirty(obj any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracker.MarkDirty(obj)
+}
+
+// MarkVariableDirty marks a variable as changed for DetectDirty.
+func (s *SyncTracker) MarkVariableDirty(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracker.MarkVariableDirty(id)
+}
+
+// DetectDirty checks only the variables marked dirty; see Tracker.DetectDirty.
+func (s *SyncTracker) DetectDirty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.DetectDirty()
+}
+
+// GetChanges returns the sorted changes and clears them.
+// Unlike Tracker.GetChanges, the result is a copy, so it stays valid after
+// other goroutines detect more changes.
+func (s *SyncTracker) GetChanges() []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := s.tracker.GetChanges()
+	return append(make([]Change, 0, len(changes)), changes...)
+}
+
+// RegisterObject registers an object and returns its ID.
+func (s *SyncTracker) RegisterObject(obj any) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.RegisterObject(obj)
+}
+
+// UnregisterObject removes an object from the registry.
+func (s *SyncTracker) UnregisterObject(obj any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracker.UnregisterObject(obj)
+}
+
+// LookupObject finds the object ID for a registered object.
+func (s *SyncTracker) LookupObject(obj any) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.LookupObject(obj)
+}
+
+// GetObject retrieves an object by its object ID.
+func (s *SyncTracker) GetObject(objID int64) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.GetObject(objID)
+}
+
+// ToValueJSON serializes a value to Value JSON form.
+func (s *SyncTracker) ToValueJSON(value any) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.ToValueJSON(value)
+}
+
+// ToValueJSONBytes serializes a value to Value JSON as a byte slice.
+func (s *SyncTracker) ToValueJSONBytes(value any) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.ToValueJSONBytes(value)
+}
+
+// FromValueJSONBytes parses Value JSON, resolving object references.
+func (s *SyncTracker) FromValueJSONBytes(value []byte) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.FromValueJSONBytes(value)
+}
@@ -0,0 +1,128 @@
+package changetracker
+
+import (
+	"sync"
+	"testing"
+)
+
+// ============================================================================
+// SyncTracker Tests (test-SyncTracker.md)
+// ============================================================================
+
+// ST1.1: NewSyncTracker with nil creates a tracker
+func TestSyncTracker_NewNil(t *testing.T) {
+	s := NewSyncTracker(nil)
+	s.Do(func(tr *Tracker) {
+		if tr == nil {
+			t.Fatal("expected wrapped tracker")
+		}
+	})
+}
+
+// ST1.2: ID-based Get/Set/SetProperty
+func TestSyncTracker_IDMethods(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+
+	if err := s.Set(name.ID, "Bob"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	val, err := s.Get(name.ID)
+	if err != nil || val != "Bob" {
+		t.Errorf("expected Bob, got %v (%v)", val, err)
+	}
+	if err := s.SetProperty(name.ID, "label", "Name"); err != nil {
+		t.Fatalf("SetProperty failed: %v", err)
+	}
+	if s.GetProperty(name.ID, "label") != "Name" {
+		t.Error("expected label property")
+	}
+	if err := s.SetProperty(name.ID, "access", "rx"); errorType(err) != BadAccessValue {
+		t.Errorf("expected BadAccessValue, got %v", err)
+	}
+	if _, err := s.TryCreateVariable(nil, root.ID, "Name?access=bad", nil); errorType(err) != BadAccessValue {
+		t.Errorf("expected BadAccessValue from TryCreateVariable, got %v", err)
+	}
+	if err := s.Set(999, 1); err == nil {
+		t.Error("expected NotFound error for missing variable")
+	} else if ve, ok := err.(*VariableError); !ok || ve.ErrorType != NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+// ST1.3: GetChanges returns a copy
+func TestSyncTracker_GetChangesCopy(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+
+	s.Do(func(*Tracker) { p.Name = "Bob" })
+	s.DetectChanges()
+	first := s.GetChanges()
+	if len(first) != 1 || first[0].VariableID != name.ID {
+		t.Fatalf("expected one change for %d, got %v", name.ID, first)
+	}
+	s.SetProperty(root.ID, "label", "x")
+	s.GetChanges()
+	if first[0].VariableID != name.ID {
+		t.Error("earlier GetChanges result was overwritten")
+	}
+}
+
+// ST1.4: concurrent mutation and detection (run with -race)
+func TestSyncTracker_Concurrent(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	age := s.CreateVariable(nil, root.ID, "Age", nil)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := range 50 {
+				switch j % 4 {
+				case 0:
+					s.Set(age.ID, i*j)
+				case 1:
+					v := s.CreateVariable(nil, root.ID, "Name", nil)
+					s.DestroyVariable(v.ID)
+				case 2:
+					s.Do(func(*Tracker) { p.Age++ })
+				default:
+					s.DetectChanges()
+					s.GetChanges()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	s.Do(func(tr *Tracker) {
+		if len(tr.Variables()) != 2 {
+			t.Errorf("expected 2 variables, got %d", len(tr.Variables()))
+		}
+	})
+}
+
+// ST1.5: Do makes mutation and detection atomic
+func TestSyncTracker_DoAtomic(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+
+	var changes []Change
+	s.Do(func(tr *Tracker) {
+		p.Name = "Bob"
+		tr.DetectChanges()
+		changes = tr.GetChanges()
+	})
+	if len(changes) != 1 || changes[0].VariableID != name.ID {
+		t.Errorf("expected change for %d, got %v", name.ID, changes)
+	}
+}
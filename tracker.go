@@ -4,11 +4,14 @@
 package changetracker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"maps"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 	"weak"
 )
 
@@ -105,7 +108,23 @@ type Change struct {
 	VariableID        int64
 	Priority          Priority
 	ValueChanged      bool
+	ParentChanged     bool // moved to another parent (see MoveVariable)
+	Destroyed         bool // destroyed (reported only by ChangesSince)
 	PropertiesChanged []string
+
+	// Details, populated only when Tracker.ChangeDetails is set.
+	// Old values are from before the first change since the last GetChanges.
+	OldValueJSON   any               // previous ValueJSON (value changes only)
+	NewValueJSON   any               // current ValueJSON (value changes only)
+	OldWrapperJSON any               // previous WrapperJSON (value changes only)
+	NewWrapperJSON any               // current WrapperJSON (value changes only)
+	OldProperties  map[string]string // previous values of PropertiesChanged ("" if unset)
+	NewProperties  map[string]string // current values of PropertiesChanged ("" if removed)
+
+	// ArrayOps turns the previous array value into the current one.
+	// Set only for value changes of variables with "diff=array" whose old and new
+	// values are both arrays; nil means the whole value must be resent.
+	ArrayOps []ArrayOp
 }
 
 // weakEntry holds a weak reference to an object and its object ID (for ObjectRef serialization).
@@ -116,26 +135,86 @@ type weakEntry struct {
 
 // propertyChange tracks which properties changed for a variable.
 type propertyChange struct {
-	properties map[string]bool // set of changed property names
+	properties map[string]bool   // set of changed property names
+	old        map[string]string // previous property values (only with ChangeDetails)
+}
+
+// valueSnapshot holds a variable's Value JSON from before its first recorded change.
+type valueSnapshot struct {
+	valueJSON   any
+	wrapperJSON any
 }
 
 // Tracker is the central change tracker.
 // CRC: crc-Tracker.md
 // Spec: main.md, api.md
 type Tracker struct {
-	Resolver Resolver // defaults to the tracker itself
+	Resolver       Resolver    // defaults to the tracker itself
+	ChangeDetails  bool        // when true, GetChanges includes old and new values
+	OrphanChildren bool        // when true, DestroyVariable leaves descendants in place
+	VariableIDs    IDAllocator // allocates variable IDs; nil uses the shared counter
+	ObjectIDs      IDAllocator // allocates registered object IDs; nil uses the shared counter
+	Clock          Clock       // time source for poll intervals; nil uses the system clock
 
 	variables map[int64]*Variable
-	nextID    int64
-	rootIDs   map[int64]bool // set of root variable IDs for efficient tree traversal
+	nextID    int64           // shared counter used when VariableIDs or ObjectIDs is nil
+	seq       int64           // change sequence, bumped for every recorded change, creation, and destruction
+	destroyed map[int64]int64 // sequence numbers of destroyed variables, while checkpoints need them
+	creating  int64           // ID of the variable being created, reserved until it is stored
+	reserved  map[int64]bool  // variable IDs reserved while Restore runs
+	rootIDs   map[int64]bool  // set of root variable IDs for efficient tree traversal
 
 	// Change tracking
 	valueChanges    map[int64]bool            // variables with value changes
+	parentChanges   map[int64]bool            // variables moved to another parent
 	PropertyChanges map[int64]*propertyChange // variables with property changes
+	oldValues       map[int64]valueSnapshot   // values before the first change (with ChangeDetails or diff=array)
 
 	// Sorted changes (reused slice)
 	sortedChanges []Change
 
+	// Subscriptions and the changes of the current detection cycle (nil without subscriptions)
+	// CRC: crc-Subscription.md
+	subscriptions []*Subscription
+	cycle         *changeRecord
+
+	// Independent change consumers
+	// CRC: crc-Cursor.md
+	cursors []*Cursor
+
+	// Version consumers, which keep destructions for ChangesSince
+	// CRC: crc-Version.md
+	checkpoints []*Checkpoint
+
+	// Undo history (nil when not recording)
+	// CRC: crc-History.md
+	history *History
+
+	// Dirty marking: variables by the registered object their cached Value is,
+	// and what was marked since the last DetectDirty
+	// CRC: crc-DirtyIndex.md
+	objectVars     map[int64]map[int64]bool
+	viaVars        map[uintptr]map[int64]bool // variables by the objects their paths navigate through
+	dirtyObjects   map[int64]bool
+	dirtyVariables map[int64]bool
+
+	// Push-based change signals: cancel functions of held ChangeNotifier objects, their
+	// callbacks (locked, since they may come from any goroutine), and the
+	// objects and variables marked for the current detection pass
+	// CRC: crc-ChangeNotifier.md
+	notifiers map[int64]func()
+	signals   *notifierSignals
+	signalled map[int64]bool
+	forced    map[int64]bool
+
+	// Variables still to check in the current budgeted pass (nil between passes)
+	// CRC: crc-BudgetedDetection.md
+	walk []int64
+
+	// Context of the current DetectChangesContext pass (nil outside one)
+	// CRC: crc-ContextResolver.md
+	ctx context.Context
+
 	// Object registry: maps object pointer to weak entry
 	// CRC: crc-ObjectRegistry.md
 	ptrToEntry map[uintptr]weakEntry
@@ -150,10 +229,19 @@ func NewTracker() *Tracker {
 		nextID:          1,
 		rootIDs:         make(map[int64]bool),
 		valueChanges:    make(map[int64]bool),
+		parentChanges:   make(map[int64]bool),
 		PropertyChanges: make(map[int64]*propertyChange),
+		oldValues:       make(map[int64]valueSnapshot),
 		sortedChanges:   make([]Change, 0, 16),
 		ptrToEntry:      make(map[uintptr]weakEntry),
 		idToPtr:         make(map[int64]uintptr),
+		objectVars:      make(map[int64]map[int64]bool),
+		viaVars:         make(map[uintptr]map[int64]bool),
+		destroyed:       make(map[int64]int64),
+		dirtyObjects:    make(map[int64]bool),
+		dirtyVariables:  make(map[int64]bool),
+		notifiers:       make(map[int64]func()),
+		signals:         newNotifierSignals(),
 	}
 	t.Resolver = t // default resolver is the tracker itself
 	return t
@@ -172,6 +260,14 @@ const (
 	BadParent
 	BadCall
 	NilPath
+	IDConflict
+	BadAccessValue
+	BadAccessPath
+	BadChildValue
+	Conflict
+	BadPollValue
+	BadTimeoutValue
+	Timeout
 )
 
 func (e VariableErrorType) String() string {
@@ -186,6 +282,14 @@ func (e VariableErrorType) String() string {
 		"BadParent",
 		"BadCall",
 		"NilPath",
+		"IDConflict",
+		"BadAccessValue",
+		"BadAccessPath",
+		"BadChildValue",
+		"Conflict",
+		"BadPollValue",
+		"BadTimeoutValue",
+		"Timeout",
 	}[e]
 }
 
@@ -193,6 +297,7 @@ type VariableError struct {
 	ErrorType VariableErrorType
 	Message   string
 	Cause     error
+	Current   any // current Value JSON (Conflict only)
 }
 
 func verror(typ VariableErrorType, msg string, args ...any) *VariableError {
@@ -231,33 +336,90 @@ type Variable struct {
 	WrapperValue       any      // wrapper object for child navigation (optional)
 	WrapperJSON        any      // serialized WrapperValue
 	Error              error    // error from last get or nil if none
+	Version            int64    // tracker sequence number of the last change or creation
 
-	tracker *Tracker
+	tracker       *Tracker
+	accessors     []*accessor      // last accessor used for each path element (default resolver only)
+	valueVersion  int64            // sequence number of the last value change
+	parentVersion int64            // sequence number of the last parent change
+	objID         int64            // registered object ID of Value in the dirty index (0 = none)
+	via           []uintptr        // objects the path last navigated through, in the dirty index
+	pollInterval  time.Duration    // minimum time between reads (poll property, 0 = every pass)
+	lastPoll      time.Time        // when the value was last read on schedule
+	timeout       time.Duration    // maximum time for a read (timeout property, 0 = none)
+	propVersions  map[string]int64 // sequence number of each property's last change
 }
 
 func (t *Tracker) ChangeAll(varID int64) {
-	t.valueChanges[varID] = true
-	for prop := range t.variables[varID].Properties {
+	v := t.variables[varID]
+	t.recordValueChange(v)
+	for prop := range v.Properties {
 		t.RecordPropertyChange(varID, prop)
 	}
 }
 
-// CreateVariable creates a new variable in the tracker with an auto-assigned ID.
+// CreateVariable creates a new variable in the tracker with an ID from VariableIDs.
+// Returns nil if no unused ID can be allocated.
+// Panics on an invalid path, access value, or access/path combination.
 // Sequence: seq-create-variable.md
 func (t *Tracker) CreateVariable(value any, parentID int64, path string, properties map[string]string) *Variable {
-	id := t.nextID
-	t.nextID += 1
+	id, err := t.allocateID(t.VariableIDs)
+	if err != nil {
+		return nil
+	}
 	return t.CreateVariableWithId(id, value, parentID, path, properties)
 }
 
+// TryCreateVariable is like CreateVariable but returns a *VariableError instead
+// of panicking or returning nil.
+// Sequence: seq-create-variable.md
+func (t *Tracker) TryCreateVariable(value any, parentID int64, path string, properties map[string]string) (*Variable, error) {
+	id, err := t.allocateID(t.VariableIDs)
+	if err != nil {
+		return nil, err
+	}
+	return t.TryCreateVariableWithId(id, value, parentID, path, properties)
+}
+
 // CreateVariableWithId creates a new variable in the tracker with a caller-specified ID.
 // Returns nil if the ID is already in use.
+// Panics on an invalid path, access value, or access/path combination.
 // Sequence: seq-create-variable.md
 func (t *Tracker) CreateVariableWithId(id int64, value any, parentID int64, path string, properties map[string]string) *Variable {
-	// Check if ID is already in use
+	v, err := t.TryCreateVariableWithId(id, value, parentID, path, properties)
+	if err != nil {
+		if err.(*VariableError).ErrorType == IDConflict {
+			return nil
+		}
+		panic(fmt.Sprintf("CreateVariable: %v", err))
+	}
+	return v
+}
+
+// TryCreateVariableWithId is like CreateVariableWithId but returns a *VariableError
+// instead of panicking or returning nil:
+//   - IDConflict: the ID is 0 or already used by a variable or a registered object
+//   - BadSetterCall: a setter call (_) is not at the end of the path
+//   - BadAccessValue: the access property is not r, w, rw, or action
+//   - BadAccessPath: the access mode does not fit the path ending
+//   - BadChildValue: a child variable was given a value
+//
+// Nothing is changed when an error is returned.
+// Sequence: seq-create-variable.md
+func (t *Tracker) TryCreateVariableWithId(id int64, value any, parentID int64, path string, properties map[string]string) (*Variable, error) {
+	if id == 0 {
+		return nil, verror(IDConflict, "ID 0 is reserved")
+	}
 	if _, exists := t.variables[id]; exists {
-		return nil
+		return nil, verror(IDConflict, "ID %d is already used by a variable", id)
 	}
+	if _, exists := t.idToPtr[id]; exists {
+		return nil, verror(IDConflict, "ID %d is already used by a registered object", id)
+	}
+	// Reserve the ID so objects registered while computing the value cannot take it
+	prevCreating := t.creating
+	t.creating = id
+	defer func() { t.creating = prevCreating }()
 
 	if properties == nil {
 		properties = make(map[string]string)
@@ -295,7 +457,7 @@ func (t *Tracker) CreateVariableWithId(id int64, value any, parentID int64, path
 		v.Path = parsePath(pathPart)
 		// Validate path: setter (_) must be at terminal position
 		if err := validatePath(v.Path); err != nil {
-			panic(fmt.Sprintf("CreateVariable: %v", err))
+			return nil, err
 		}
 	}
 
@@ -307,11 +469,25 @@ func (t *Tracker) CreateVariableWithId(id int64, value any, parentID int64, path
 	// Set Access from access property
 	if accessStr, ok := v.Properties["access"]; ok {
 		if !isValidAccess(accessStr) {
-			panic(fmt.Sprintf("CreateVariable: invalid access value %q (must be r, w, rw, or action)", accessStr))
+			return nil, invalidAccess(accessStr)
 		}
 		v.Access = accessStr
 	}
 
+	// Set the polling interval from the poll property
+	pollInterval, err := parsePoll(v.Properties["poll"])
+	if err != nil {
+		return nil, err
+	}
+	v.pollInterval = pollInterval
+
+	// Set the read timeout from the timeout property
+	timeout, err := parseTimeout(v.Properties["timeout"])
+	if err != nil {
+		return nil, err
+	}
+	v.timeout = timeout
+
 	hadType := v.Properties["type"] != ""
 
 	//for prop := range v.Properties {
@@ -320,7 +496,11 @@ func (t *Tracker) CreateVariableWithId(id int64, value any, parentID int64, path
 
 	// Validate access/path combination
 	if err := validateAccessPath(v.GetAccess(), v.Path); err != nil {
-		panic(fmt.Sprintf("CreateVariable: %v", err))
+		return nil, err
+	}
+	// Child variables derive their value from the parent via path
+	if parentID != 0 && value != nil {
+		return nil, verror(BadChildValue, "cannot provide both parentID and value; child variables derive value from parent via path")
 	}
 
 	// Cache value and manage tree structure
@@ -329,10 +509,6 @@ func (t *Tracker) CreateVariableWithId(id int64, value any, parentID int64, path
 		v.Value = value
 		t.rootIDs[v.ID] = true
 	} else {
-		// Child variable: must have path, cannot have value
-		if value != nil {
-			panic("CreateVariable: cannot provide both parentID and value; child variables derive value from parent via path")
-		}
 		// Add to parent's ChildIDs
 		if parent := t.variables[parentID]; parent != nil {
 			parent.ChildIDs = append(parent.ChildIDs, v.ID)
@@ -370,7 +546,12 @@ func (t *Tracker) CreateVariableWithId(id int64, value any, parentID int64, path
 	}
 
 	t.variables[v.ID] = v
-	return v
+	t.indexValue(v)
+	if v.pollInterval > 0 {
+		v.lastPoll = t.now()
+	}
+	t.versionCreated(v)
+	return v, nil
 }
 
 // parsePathWithQuery splits a path into the path portion and query parameters.
@@ -478,7 +659,7 @@ func validateAccessPath(access string, path []any) error {
 	if isGetterCall(lastElem) {
 		// () paths require access "rw", "r", or "action" (not "w")
 		if access == "w" {
-			return verror(BadAccess, "path ending in %q requires access \"rw\", \"r\", or \"action\", not %q", lastElem, access)
+			return verror(BadAccessPath, "path ending in %q requires access \"rw\", \"r\", or \"action\", not %q", lastElem, access)
 		}
 	}
 
@@ -486,7 +667,7 @@ func validateAccessPath(access string, path []any) error {
 	if isSetterCall(lastElem) {
 		// (_) paths require access "w" or "action" (not "r" or "rw")
 		if access == "r" || access == "rw" {
-			return verror(BadAccess, "path ending in %q requires access \"w\" or \"action\", not %q", lastElem, access)
+			return verror(BadAccessPath, "path ending in %q requires access \"w\" or \"action\", not %q", lastElem, access)
 		}
 	}
 
@@ -518,62 +699,236 @@ func (t *Tracker) GetVariable(id int64) *Variable {
 	return t.variables[id]
 }
 
-// DestroyVariable removes a variable from the tracker.
+// DestroyVariable removes a variable and its descendants from the tracker.
+// With OrphanChildren, only the variable itself is removed.
 // CRC: crc-Tracker.md
 // Sequence: seq-destroy-variable.md
 func (t *Tracker) DestroyVariable(id int64) {
-	v, ok := t.variables[id]
-	if !ok {
+	if t.OrphanChildren {
+		t.destroyVariable(id)
 		return
 	}
+	t.DestroySubtree(id)
+}
 
-	// Remove from rootIDs if root variable
-	if v.ParentID == 0 {
-		delete(t.rootIDs, id)
-	} else {
-		// Remove from parent's ChildIDs if child variable
-		if parent := t.variables[v.ParentID]; parent != nil {
-			for i, childID := range parent.ChildIDs {
-				if childID == id {
-					parent.ChildIDs = append(parent.ChildIDs[:i], parent.ChildIDs[i+1:]...)
-					break
-				}
-			}
+// DestroySubtree removes a variable and its descendants depth-first, children
+// before parents, and returns the destroyed IDs in that order.
+// CRC: crc-Tracker.md
+// Sequence: seq-destroy-variable.md
+func (t *Tracker) DestroySubtree(id int64) []int64 {
+	var destroyed []int64
+	var destroy func(id int64)
+	destroy = func(id int64) {
+		v := t.variables[id]
+		if v == nil {
+			return
+		}
+		// Destroying a child edits v.ChildIDs
+		for _, childID := range slices.Clone(v.ChildIDs) {
+			destroy(childID)
 		}
+		t.destroyVariable(id)
+		destroyed = append(destroyed, id)
+	}
+	destroy(id)
+	return destroyed
+}
+
+// destroyVariable removes one variable, unregistering its value and wrapper,
+// and versions the destruction.
+func (t *Tracker) destroyVariable(id int64) {
+	if t.removeVariable(id) {
+		t.versionDestroyed(id)
+	}
+}
+
+// removeVariable removes one variable, unregistering its value and wrapper,
+// without versioning. Returns false if there is no such variable.
+func (t *Tracker) removeVariable(id int64) bool {
+	v, ok := t.variables[id]
+	if !ok {
+		return false
 	}
 
+	// Remove from rootIDs or the parent's ChildIDs
+	t.detach(v)
+	objID := v.objID
+	t.unindexValue(v)
+	t.indexVia(v, nil)
+
 	// Unregister wrapper if it was registered
 	if v.WrapperValue != nil {
 		t.UnregisterObject(v.WrapperValue)
 	}
 
-	// Unregister object if it was registered
-	if v.Value != nil {
+	// Unregister object if it was registered and no other variable holds it
+	if v.Value != nil && len(t.objectVars[objID]) == 0 {
 		t.UnregisterObject(v.Value)
 	}
 
 	// Remove from change tracking
-	delete(t.valueChanges, id)
-	delete(t.PropertyChanges, id)
+	t.pending().forget(id)
+	if t.cycle != nil {
+		t.cycle.forget(id)
+	}
+	for _, c := range t.cursors {
+		c.forget(id)
+	}
 
 	// Remove from variables
 	delete(t.variables, id)
+	return true
+}
+
+// MoveVariable moves a variable, with its descendants, under newParentID
+// (0 makes it a root) and gives it newPath (empty for no path; query
+// parameters are not allowed). The path is validated against the variable's
+// access before anything changes. A parent change is recorded, along with a
+// path property change if the path differs, and the variable and its active
+// descendants are re-resolved, recording value changes. A variable moved to
+// the root keeps its current value.
+// Fails with NotFound for an unknown variable and BadParent for an unknown
+// parent or a parent inside the variable's own subtree.
+// CRC: crc-Tracker.md
+// Sequence: seq-move-variable.md
+func (t *Tracker) MoveVariable(id, newParentID int64, newPath string) error {
+	v := t.variables[id]
+	if v == nil {
+		return verror(NotFound, "variable %d not found", id)
+	}
+	if newParentID != 0 {
+		if t.variables[newParentID] == nil {
+			return verror(BadParent, "parent variable %d not found", newParentID)
+		}
+		for p := t.variables[newParentID]; p != nil; p = t.variables[p.ParentID] {
+			if p.ID == id {
+				return verror(BadParent, "cannot move variable %d under its own descendant %d", id, newParentID)
+			}
+		}
+	}
+	if strings.Contains(newPath, "?") {
+		return verror(PathError, "move path %q cannot have query parameters", newPath)
+	}
+	var path []any
+	if newPath != "" {
+		path = parsePath(newPath)
+	}
+	if err := validatePath(path); err != nil {
+		return err
+	}
+	if err := validateAccessPath(v.GetAccess(), path); err != nil {
+		return err
+	}
+
+	if v.ParentID != newParentID {
+		t.detach(v)
+		v.ParentID = newParentID
+		if newParentID == 0 {
+			t.rootIDs[id] = true
+		} else {
+			parent := t.variables[newParentID]
+			parent.ChildIDs = append(parent.ChildIDs, id)
+		}
+		t.recordParentChange(v)
+	}
+	if oldPath := v.Properties["path"]; oldPath != newPath {
+		if newPath == "" {
+			delete(v.Properties, "path")
+		} else {
+			v.Properties["path"] = newPath
+		}
+		t.recordPropertyChange(id, "path", oldPath)
+	}
+	v.Path = path
+	v.accessors = nil
+
+	// Re-resolve from the new position. The values found there are not edits,
+	// so they are kept out of undo history.
+	if h := t.history; h != nil && !h.applying {
+		h.applying = true
+		defer func() { h.applying = false }()
+	}
+	t.resolve(v)
+	return nil
+}
+
+// resolve re-reads a moved variable and its descendants. Unlike
+// checkVariable it ignores Active, poll schedules and cancellation: the
+// cached values belong to the old position and must not survive the move.
+func (t *Tracker) resolve(v *Variable) {
+	if v.IsReadable() {
+		t.refresh(v)
+	} else if v.ParentID != 0 && !v.IsAction() {
+		v.Value, _ = v.GetValue()
+		t.indexValue(v)
+	}
+	for _, childID := range v.ChildIDs {
+		if child := t.variables[childID]; child != nil {
+			t.resolve(child)
+		}
+	}
+}
+
+// detach removes a variable from its parent's ChildIDs, or from rootIDs.
+func (t *Tracker) detach(v *Variable) {
+	if v.ParentID == 0 {
+		delete(t.rootIDs, v.ID)
+		return
+	}
+	if parent := t.variables[v.ParentID]; parent != nil {
+		if i := slices.Index(parent.ChildIDs, v.ID); i >= 0 {
+			parent.ChildIDs = slices.Delete(parent.ChildIDs, i, i+1)
+		}
+	}
 }
 
 // DetectChanges compares current values to cached ValueJSON using tree traversal,
 // sorts changes by priority, clears internal change records, and returns the sorted changes.
+// Subscriptions are then notified with the changes of this cycle.
 // CRC: crc-Tracker.md
 // Sequence: seq-detect-changes.md
 func (t *Tracker) DetectChanges() bool {
+	changed, _ := t.DetectChangesContext(context.Background())
+	return changed
+}
+
+// DetectChangesContext is DetectChanges with a context. Reads use ctx (see
+// ContextResolver). Once ctx is done the pass stops early: the changes found
+// so far are recorded and notified, and the dirty marks are kept for the next
+// pass. Returns whether any value changed, and ctx.Err() if the pass stopped early.
+// CRC: crc-Tracker.md, crc-ContextResolver.md
+// Sequence: seq-detect-changes.md
+func (t *Tracker) DetectChangesContext(ctx context.Context) (bool, error) {
 	// Perform depth-first traversal starting from root variables
 	changed := false
 	for _, v := range t.variables {
 		v.Error = nil
 	}
+	// A full pass covers everything marked dirty
+	marked := t.takeMarks()
+	defer t.endPass()
+	t.ctx = ctx
+	if h := t.history; h != nil {
+		// One undo step per detection
+		h.Begin()
+		defer h.End()
+	}
 	for rootID := range t.rootIDs {
 		changed = t.checkVariable(rootID) || changed
 	}
-	return changed
+	// The walk skips quiet notifiers' children, so check marked variables there
+	for _, id := range slices.Sorted(maps.Keys(marked)) {
+		if t.underQuiet(id) {
+			changed = t.checkVariable(id) || changed
+		}
+	}
+	err := ctx.Err()
+	if err != nil {
+		// The variables the pass missed may include marked ones
+		t.restoreMarks()
+	}
+	t.notify()
+	return changed, err
 }
 
 func (t *Tracker) GetChanges() []Change {
@@ -581,16 +936,19 @@ func (t *Tracker) GetChanges() []Change {
 	result := t.sortChanges()
 	// Clear internal change records (but preserve the sorted changes slice)
 	t.valueChanges = make(map[int64]bool)
+	t.parentChanges = make(map[int64]bool)
 	t.PropertyChanges = make(map[int64]*propertyChange)
+	clear(t.oldValues)
 	return result
 }
 
 // checkVariable recursively checks a variable and its children for changes.
 // If the variable is inactive, it and all its descendants are skipped.
+// Nothing is checked once the context of a DetectChangesContext pass is done.
 // If the variable is non-readable (write-only or action), it is skipped but children are still checked.
 func (t *Tracker) checkVariable(id int64) bool {
 	v := t.variables[id]
-	if v == nil {
+	if v == nil || t.cancelled() {
 		return false
 	}
 
@@ -601,6 +959,14 @@ func (t *Tracker) checkVariable(id int64) bool {
 
 	changed := false
 
+	// Not due yet: skip it and the descendants that follow its schedule
+	if !t.due(v) {
+		for _, childID := range t.scheduled(v) {
+			changed = t.checkVariable(childID) || changed
+		}
+		return changed
+	}
+
 	// If non-readable (write-only or action), skip this variable but continue to children
 	// (non-readable variables cannot be read, so we can't detect their value changes)
 	if !v.IsReadable() {
@@ -611,25 +977,10 @@ func (t *Tracker) checkVariable(id int64) bool {
 		return changed
 	}
 
-	// Get current value (use GetValue to bypass access checks - we've already verified readable above)
-	currentValue, err := v.GetValue()
-	if err == nil {
-		// Convert to Value JSON
-		currentJSON := t.ToValueJSON(currentValue)
-
-		// Compare with cached ValueJSON
-		if !jsonEqual(v.ValueJSON, currentJSON) {
-			changed = true
-			t.valueChanges[v.ID] = true
-
-			// Update cached values
-			v.Value = currentValue
-			v.ValueJSON = currentJSON
-
-			// Update wrapper after ValueJSON is updated
-			v.updateWrapper()
-			v.SetType()
-		}
+	changed = t.refresh(v)
+	// Children of an unchanged notifier that has not signalled have nothing to find
+	if !changed && t.quiet(v) {
+		return false
 	}
 
 	// Recursively check all children
@@ -639,15 +990,36 @@ func (t *Tracker) checkVariable(id int64) bool {
 	return changed
 }
 
-// jsonEqual compares two Value JSON values for equality.
-func jsonEqual(a, b any) bool {
-	// Use JSON serialization for comparison
-	aBytes, err1 := json.Marshal(a)
-	bBytes, err2 := json.Marshal(b)
-	if err1 != nil || err2 != nil {
-		return reflect.DeepEqual(a, b)
+// refresh reads a readable variable's current value and, if its Value JSON
+// differs from the cached ValueJSON, records a value change and updates the cache.
+func (t *Tracker) refresh(v *Variable) bool {
+	// Get current value (use GetValue to bypass access checks)
+	currentValue, err := v.GetValue()
+	if err != nil {
+		return false
+	}
+	// Convert to Value JSON
+	currentJSON := t.ToValueJSON(currentValue)
+
+	// Compare with cached ValueJSON
+	if jsonEqual(v.ValueJSON, currentJSON) {
+		return false
+	}
+	t.recordValueChange(v)
+	oldJSON := v.ValueJSON
+
+	// Update cached values
+	v.Value = currentValue
+	t.indexValue(v)
+	v.ValueJSON = currentJSON
+
+	// Update wrapper after ValueJSON is updated
+	v.updateWrapper()
+	v.SetType()
+	if t.history != nil {
+		t.history.record(v, oldJSON)
 	}
-	return string(aBytes) == string(bBytes)
+	return true
 }
 
 // sortChanges returns changes sorted by priority (high -> medium -> low).
@@ -656,8 +1028,13 @@ func jsonEqual(a, b any) bool {
 // Sequence: seq-detect-changes.md
 func (t *Tracker) sortChanges() []Change {
 	// Reset the reusable slice
-	t.sortedChanges = t.sortedChanges[:0]
+	t.sortedChanges = t.buildChanges(t.sortedChanges[:0], t.pending(), t.ChangeDetails)
+	return t.sortedChanges
+}
 
+// buildChanges appends the changes in rec to out, sorted by priority, with old and
+// new values if details is set.
+func (t *Tracker) buildChanges(out []Change, rec changeRecord, details bool) []Change {
 	// Collect changes by priority
 	highChanges := make([]Change, 0)
 	mediumChanges := make([]Change, 0)
@@ -665,10 +1042,13 @@ func (t *Tracker) sortChanges() []Change {
 
 	// Build combined set of changed variable IDs from valueChanges and propertyChanges
 	changedIDs := make(map[int64]bool)
-	for id := range t.valueChanges {
+	for id := range rec.values {
 		changedIDs[id] = true
 	}
-	for id := range t.PropertyChanges {
+	for id := range rec.properties {
+		changedIDs[id] = true
+	}
+	for id := range rec.parents {
 		changedIDs[id] = true
 	}
 
@@ -679,8 +1059,9 @@ func (t *Tracker) sortChanges() []Change {
 			continue
 		}
 
-		valueChanged := t.valueChanges[id]
-		propChange := t.PropertyChanges[id]
+		valueChanged := rec.values[id]
+		parentChanged := rec.parents[id]
+		propChange := rec.properties[id]
 
 		// Group properties by priority
 		highProps := make([]string, 0)
@@ -701,8 +1082,8 @@ func (t *Tracker) sortChanges() []Change {
 			}
 		}
 
-		// Add value change at its priority level
-		if valueChanged {
+		// Add value and parent changes at the value's priority level
+		if valueChanged || parentChanged {
 			switch v.ValuePriority {
 			case PriorityHigh:
 				// Combine with high-priority properties or create new
@@ -710,15 +1091,17 @@ func (t *Tracker) sortChanges() []Change {
 					highChanges = append(highChanges, Change{
 						VariableID:        id,
 						Priority:          PriorityHigh,
-						ValueChanged:      true,
+						ValueChanged:      valueChanged,
+						ParentChanged:     parentChanged,
 						PropertiesChanged: highProps,
 					})
 					highProps = nil // consumed
 				} else {
 					highChanges = append(highChanges, Change{
-						VariableID:   id,
-						Priority:     PriorityHigh,
-						ValueChanged: true,
+						VariableID:    id,
+						Priority:      PriorityHigh,
+						ValueChanged:  valueChanged,
+						ParentChanged: parentChanged,
 					})
 				}
 			case PriorityLow:
@@ -726,15 +1109,17 @@ func (t *Tracker) sortChanges() []Change {
 					lowChanges = append(lowChanges, Change{
 						VariableID:        id,
 						Priority:          PriorityLow,
-						ValueChanged:      true,
+						ValueChanged:      valueChanged,
+						ParentChanged:     parentChanged,
 						PropertiesChanged: lowProps,
 					})
 					lowProps = nil
 				} else {
 					lowChanges = append(lowChanges, Change{
-						VariableID:   id,
-						Priority:     PriorityLow,
-						ValueChanged: true,
+						VariableID:    id,
+						Priority:      PriorityLow,
+						ValueChanged:  valueChanged,
+						ParentChanged: parentChanged,
 					})
 				}
 			default: // Medium
@@ -742,15 +1127,17 @@ func (t *Tracker) sortChanges() []Change {
 					mediumChanges = append(mediumChanges, Change{
 						VariableID:        id,
 						Priority:          PriorityMedium,
-						ValueChanged:      true,
+						ValueChanged:      valueChanged,
+						ParentChanged:     parentChanged,
 						PropertiesChanged: mediumProps,
 					})
 					mediumProps = nil
 				} else {
 					mediumChanges = append(mediumChanges, Change{
-						VariableID:   id,
-						Priority:     PriorityMedium,
-						ValueChanged: true,
+						VariableID:    id,
+						Priority:      PriorityMedium,
+						ValueChanged:  valueChanged,
+						ParentChanged: parentChanged,
 					})
 				}
 			}
@@ -781,23 +1168,173 @@ func (t *Tracker) sortChanges() []Change {
 	}
 
 	// Concatenate in priority order: high, medium, low
-	t.sortedChanges = append(t.sortedChanges, highChanges...)
-	t.sortedChanges = append(t.sortedChanges, mediumChanges...)
-	t.sortedChanges = append(t.sortedChanges, lowChanges...)
+	start := len(out)
+	out = append(out, highChanges...)
+	out = append(out, mediumChanges...)
+	out = append(out, lowChanges...)
+
+	for i := start; i < len(out); i++ {
+		c := &out[i]
+		if details {
+			t.addChangeDetails(c, rec)
+		}
+		if c.ValueChanged {
+			t.addArrayOps(c, rec)
+		}
+	}
+	return out
+}
 
-	return t.sortedChanges
+// addChangeDetails fills in the old and new values for a change.
+func (t *Tracker) addChangeDetails(c *Change, rec changeRecord) {
+	v := t.variables[c.VariableID]
+	if c.ValueChanged {
+		old, ok := rec.oldValues[c.VariableID]
+		if !ok {
+			old = valueSnapshot{v.ValueJSON, v.WrapperJSON}
+		}
+		c.OldValueJSON = old.valueJSON
+		c.NewValueJSON = v.ValueJSON
+		c.OldWrapperJSON = old.wrapperJSON
+		c.NewWrapperJSON = v.WrapperJSON
+	}
+	if len(c.PropertiesChanged) > 0 {
+		pc := rec.properties[c.VariableID]
+		c.OldProperties = make(map[string]string, len(c.PropertiesChanged))
+		c.NewProperties = make(map[string]string, len(c.PropertiesChanged))
+		for _, name := range c.PropertiesChanged {
+			c.OldProperties[name] = pc.old[name]
+			c.NewProperties[name] = v.Properties[name]
+		}
+	}
+}
+
+// addArrayOps computes array diff operations for a value change of a diff=array variable.
+func (t *Tracker) addArrayOps(c *Change, rec changeRecord) {
+	v := t.variables[c.VariableID]
+	if v.Properties["diff"] != "array" {
+		return
+	}
+	old, ok1 := rec.oldValues[c.VariableID].valueJSON.([]any)
+	cur, ok2 := v.ValueJSON.([]any)
+	if !ok1 || !ok2 {
+		return
+	}
+	if ops, ok := diffArrays(old, cur); ok && len(ops) > 0 {
+		c.ArrayOps = ops
+	}
+}
+
+// changeRecord accumulates changes until they are read.
+// The tracker keeps one for GetChanges and, while there are subscriptions, one for
+// the current detection cycle.
+type changeRecord struct {
+	values     map[int64]bool            // variables with value changes
+	parents    map[int64]bool            // variables moved to another parent
+	properties map[int64]*propertyChange // variables with property changes
+	oldValues  map[int64]valueSnapshot   // values before the first change (with ChangeDetails or diff=array)
+}
+
+func newChangeRecord() *changeRecord {
+	return &changeRecord{
+		values:     make(map[int64]bool),
+		parents:    make(map[int64]bool),
+		properties: make(map[int64]*propertyChange),
+		oldValues:  make(map[int64]valueSnapshot),
+	}
+}
+
+// pending returns the record read by GetChanges.
+func (t *Tracker) pending() changeRecord {
+	return changeRecord{t.valueChanges, t.parentChanges, t.PropertyChanges, t.oldValues}
+}
+
+// recordValue records a value change, keeping the previous Value JSON from the first change if keepOld.
+func (rec changeRecord) recordValue(v *Variable, keepOld bool) {
+	if keepOld && !rec.values[v.ID] {
+		rec.oldValues[v.ID] = valueSnapshot{v.ValueJSON, v.WrapperJSON}
+	}
+	rec.values[v.ID] = true
+}
+
+// recordProperty records a property change, keeping the previous value from the first change if keepOld.
+func (rec changeRecord) recordProperty(varID int64, propName, old string, keepOld bool) {
+	pc := rec.properties[varID]
+	if pc == nil {
+		pc = &propertyChange{properties: make(map[string]bool)}
+		rec.properties[varID] = pc
+	}
+	if keepOld && !pc.properties[propName] {
+		if pc.old == nil {
+			pc.old = make(map[string]string)
+		}
+		pc.old[propName] = old
+	}
+	pc.properties[propName] = true
+}
+
+// has reports whether the record has changes for a variable.
+func (rec changeRecord) has(id int64) bool {
+	return rec.values[id] || rec.parents[id] || rec.properties[id] != nil
+}
+
+// forget drops all changes for a variable.
+func (rec changeRecord) forget(id int64) {
+	delete(rec.values, id)
+	delete(rec.parents, id)
+	delete(rec.properties, id)
+	delete(rec.oldValues, id)
+}
+
+// recordValueChange records that a variable's value changed.
+// With ChangeDetails or diff=array, the current (soon to be previous) Value JSON is kept from the first change.
+func (t *Tracker) recordValueChange(v *Variable) {
+	keepOld := t.ChangeDetails || v.Properties["diff"] == "array"
+	t.versionValue(v)
+	t.pending().recordValue(v, keepOld)
+	if t.cycle != nil {
+		t.cycle.recordValue(v, keepOld)
+	}
+	for _, c := range t.cursors {
+		c.recordValue(v, keepOld)
+	}
+}
+
+// recordParentChange records that a variable moved to another parent.
+func (t *Tracker) recordParentChange(v *Variable) {
+	t.versionParent(v)
+	t.pending().parents[v.ID] = true
+	if t.cycle != nil {
+		t.cycle.parents[v.ID] = true
+	}
+	for _, c := range t.cursors {
+		c.recordParent(v.ID)
+	}
 }
 
 // RecordPropertyChange records that a property changed for a variable.
 // CRC: crc-Tracker.md
 // Sequence: seq-set-property.md
 func (t *Tracker) RecordPropertyChange(varID int64, propName string) {
-	pc := t.PropertyChanges[varID]
-	if pc == nil {
-		pc = &propertyChange{properties: make(map[string]bool)}
-		t.PropertyChanges[varID] = pc
+	old := ""
+	if v := t.variables[varID]; v != nil {
+		old = v.Properties[propName]
+	}
+	t.recordPropertyChange(varID, propName, old)
+}
+
+// recordPropertyChange records a property change along with the property's previous value.
+func (t *Tracker) recordPropertyChange(varID int64, propName, old string) {
+	if v := t.variables[varID]; v != nil {
+		t.versionProperty(v, propName)
+	}
+	t.pending().recordProperty(varID, propName, old, t.ChangeDetails)
+	if t.cycle != nil {
+		t.cycle.recordProperty(varID, propName, old, t.ChangeDetails)
+	}
+	for _, c := range t.cursors {
+		c.recordProperty(varID, propName, old, t.ChangeDetails)
 	}
-	pc.properties[propName] = true
 }
 
 // Variables returns all variables in the tracker.
@@ -840,6 +1377,7 @@ func (t *Tracker) Children(parentID int64) []*Variable {
 
 // RegisterObject registers an object and returns its ID.
 // Returns (id, true) if registered or already registered, (0, false) if not registerable.
+// A newly registered ChangeNotifier is subscribed to (see ChangeNotifier).
 // CRC: crc-Tracker.md, crc-ObjectRegistry.md
 // Sequence: seq-to-value-json.md
 func (t *Tracker) RegisterObject(obj any) (int64, bool) {
@@ -855,8 +1393,10 @@ func (t *Tracker) RegisterObject(obj any) (int64, bool) {
 	}
 
 	// Allocate new ID and register
-	objID := t.nextID
-	t.nextID++
+	objID, err := t.allocateID(t.ObjectIDs)
+	if err != nil {
+		return 0, false
+	}
 
 	entry := weakEntry{
 		ptr:   weak.Make(&obj),
@@ -884,6 +1424,7 @@ func (t *Tracker) UnregisterObject(obj any) {
 	if entry, ok := t.ptrToEntry[ptr]; ok {
 		delete(t.idToPtr, entry.objID)
 		delete(t.ptrToEntry, ptr)
+		t.unwatchNotifier(entry.objID)
 	}
 }
 
@@ -909,6 +1450,7 @@ func (t *Tracker) LookupObject(obj any) (int64, bool) {
 		// Object was collected, clean up
 		delete(t.idToPtr, entry.objID)
 		delete(t.ptrToEntry, ptr)
+		t.unwatchNotifier(entry.objID)
 		return 0, false
 	}
 
@@ -933,6 +1475,7 @@ func (t *Tracker) GetObject(objID int64) any {
 		// Object was collected, clean up
 		delete(t.idToPtr, objID)
 		delete(t.ptrToEntry, ptr)
+		t.unwatchNotifier(objID)
 		return nil
 	}
 
@@ -1012,8 +1555,21 @@ func (t *Tracker) resolveObj(value any) (any, error) {
 }
 
 // Get implements the Resolver interface using reflection.
+// Field and map key lookups use cached accessors compiled per type.
 // Sequence: seq-get-value.md
 func (t *Tracker) Get(obj any, pathElement any) (any, error) {
+	if name, ok := pathElement.(string); ok && obj != nil {
+		if acc := accessorFor(reflect.TypeOf(obj), name, false); acc != nil {
+			if val, ok := acc.get(obj, name); ok {
+				return val, nil
+			}
+		}
+	}
+	return t.getSlow(obj, pathElement)
+}
+
+// getSlow implements Get without cached accessors.
+func (t *Tracker) getSlow(obj any, pathElement any) (any, error) {
 	if obj == nil {
 		return nil, verror(NilPath, "cannot navigate nil value")
 	}
@@ -1041,9 +1597,9 @@ func (t *Tracker) Get(obj any, pathElement any) (any, error) {
 func (t *Tracker) GetByString(rv reflect.Value, name string) (any, error) {
 	switch rv.Kind() {
 	case reflect.Struct:
-		field := rv.FieldByName(name)
-		if !field.IsValid() {
-			return nil, verror(PathError, "field %q not found", name)
+		field, _, err := fieldsOf(rv.Type()).field(rv, name)
+		if err != nil {
+			return nil, err
 		}
 		if !field.CanInterface() {
 			return nil, verror(PathError, "field %q is unexported", name)
@@ -1080,8 +1636,32 @@ func (t *Tracker) GetByIndex(rv reflect.Value, index int) (any, error) {
 }
 
 // Call implements the Resolver interface for zero-arg method invocation.
+// Methods are looked up with cached accessors compiled per type.
+// Getters whose only parameter is a context.Context get context.Background().
 // Sequence: seq-get-value.md
 func (t *Tracker) Call(obj any, methodName string) (any, error) {
+	return t.callContext(context.Background(), obj, methodName)
+}
+
+// callContext is Call, passing ctx to getters whose only parameter is a context.Context.
+func (t *Tracker) callContext(ctx context.Context, obj any, methodName string) (any, error) {
+	if obj != nil {
+		if acc := accessorFor(reflect.TypeOf(obj), methodName, true); acc != nil {
+			if val, ok := acc.call(obj); ok {
+				return val, nil
+			}
+		}
+	}
+	return t.callSlowContext(ctx, obj, methodName)
+}
+
+// callSlow implements Call without cached accessors.
+func (t *Tracker) callSlow(obj any, methodName string) (any, error) {
+	return t.callSlowContext(context.Background(), obj, methodName)
+}
+
+// callSlowContext implements CallContext without cached accessors.
+func (t *Tracker) callSlowContext(ctx context.Context, obj any, methodName string) (any, error) {
 	if obj == nil {
 		return nil, verror(BadCall, "cannot call method on nil value")
 	}
@@ -1111,14 +1691,18 @@ func (t *Tracker) Call(obj any, methodName string) (any, error) {
 
 	mt := method.Type()
 	// Allow variadic methods (NumIn=1 but IsVariadic) to be called with zero args
-	if mt.NumIn() != 0 && !mt.IsVariadic() {
+	// and getters taking only a context
+	var args []reflect.Value
+	if mt.NumIn() == 1 && mt.In(0) == contextType {
+		args = []reflect.Value{reflect.ValueOf(ctx)}
+	} else if mt.NumIn() != 0 && !mt.IsVariadic() {
 		return nil, verror(BadCall, "method %q requires arguments (use CallWith)", methodName)
 	}
 	if mt.NumOut() == 0 {
 		return nil, verror(BadCall, "method %q returns no values", methodName)
 	}
 
-	results := method.Call(nil)
+	results := method.Call(args)
 	return results[0].Interface(), nil
 }
 
@@ -1232,9 +1816,12 @@ func (t *Tracker) Set(obj any, pathElement any, value any) error {
 func (t *Tracker) setByString(rv reflect.Value, name string, value any) error {
 	switch rv.Kind() {
 	case reflect.Struct:
-		field := rv.FieldByName(name)
-		if !field.IsValid() {
-			return verror(PathError, "field %q not found", name)
+		field, sf, err := fieldsOf(rv.Type()).field(rv, name)
+		if err != nil {
+			return err
+		}
+		if sf.readonly {
+			return verror(BadAccess, "field %q is read-only", name)
 		}
 		if !field.CanSet() {
 			return verror(PathError, "field %q is not settable", name)
@@ -1293,8 +1880,8 @@ func (v *Variable) verror(typ VariableErrorType, msg string, args ...any) *Varia
 	return e
 }
 
-func (v *Variable) nilerror(i int) *VariableError {
-	return verror(NilPath, "Nil path %s(nil!).%s", pathString(v.Path[:i]), pathString(v.Path[i:]))
+func nilerror(path []any, i int) *VariableError {
+	return verror(NilPath, "Nil path %s(nil!).%s", pathString(path[:i]), pathString(path[i:]))
 }
 
 // Get gets the variable's value by navigating from the parent's value using the path.
@@ -1333,29 +1920,52 @@ func (v *Variable) GetValue() (any, error) {
 
 	current := parent.NavigationValue()
 
-	// Apply each path element
-	for i, elem := range v.Path {
+	var val any
+	var via []uintptr
+	var err error
+	if v.timeout > 0 {
+		val, via, err = v.readTimeout(current)
+	} else {
+		val, via, err = v.navigate(v.tracker.context(), current)
+	}
+	v.Error = err
+	if err != nil {
+		return nil, err
+	}
+	v.tracker.indexVia(v, via)
+	return val, nil
+}
+
+// navigate applies the variable's path to current and returns the objects it
+// went through (see indexVia).
+func (v *Variable) navigate(ctx context.Context, current any) (any, []uintptr, error) {
+	path := v.Path
+	var via []uintptr
+	for i, elem := range path {
 		var val any
 		var err error
 
 		if current == nil {
-			err = v.nilerror(i)
+			err = nilerror(path, i)
 		} else if isGetterCall(elem) {
 			// Use Call for getter methods
-			val, err = v.tracker.Resolver.Call(current, getMethodName(elem))
+			val, err = v.call(ctx, i, current, getMethodName(elem))
 		} else {
 			// Use Get for fields, map keys, indices
-			val, err = v.tracker.Resolver.Get(current, elem)
+			val, err = v.get(ctx, i, current, elem)
 		}
 
-		v.Error = err
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if i < len(path)-1 {
+			if ptr := objectPointer(val); ptr != 0 {
+				via = append(via, ptr)
+			}
 		}
 		current = val
 	}
-
-	return current, nil
+	return current, via, nil
 }
 
 func pathString(path []any) string {
@@ -1379,13 +1989,29 @@ func (v *Variable) Set(value any) error {
 
 	// Root or no-path variable: update Value directly
 	v.Value = value
+	oldJSON := v.ValueJSON
 	v.ValueJSON = v.tracker.ToValueJSON(value)
+	v.tracker.indexValue(v)
+	changed := !jsonEqual(oldJSON, v.ValueJSON)
+	if changed {
+		// Detection will not see this change, so version it here
+		v.tracker.versionValue(v)
+	}
 	v.updateWrapper()
 	v.SetType()
-	if len(v.Path) == 0 {
-		return nil
+	var err error
+	if len(v.Path) > 0 {
+		err = v.write(value)
+	}
+	if h := v.tracker.history; h != nil && changed && err == nil {
+		h.record(v, oldJSON)
 	}
+	return err
+}
 
+// write sets the value at the variable's path through the resolver without
+// touching the variable's cache.
+func (v *Variable) write(value any) error {
 	// Check if path ends in getter () - for r access this is read-only
 	// For rw access, allow calling the method with args (variadic call)
 	// For action access, allow calling the method for side effects
@@ -1410,13 +2036,13 @@ func (v *Variable) Set(value any) error {
 		var err error
 
 		if current == nil {
-			err = v.nilerror(i)
+			err = nilerror(v.Path, i)
 		} else if isGetterCall(elem) {
 			// Use Call for getter methods during navigation
-			val, err = v.tracker.Resolver.Call(current, getMethodName(elem))
+			val, err = v.call(v.tracker.context(), i, current, getMethodName(elem))
 		} else {
 			// Use Get for fields, map keys, indices
-			val, err = v.tracker.Resolver.Get(current, elem)
+			val, err = v.get(v.tracker.context(), i, current, elem)
 		}
 
 		v.Error = err
@@ -1427,7 +2053,7 @@ func (v *Variable) Set(value any) error {
 	}
 
 	if current == nil {
-		v.Error = v.nilerror(len(v.Path) - 2)
+		v.Error = nilerror(v.Path, len(v.Path)-2)
 		return v.Error
 	}
 	// Set the value at the last path element
@@ -1457,6 +2083,59 @@ func (v *Variable) Set(value any) error {
 	return nil
 }
 
+// SetIfUnchanged sets the value only if the variable's current Value JSON equals
+// expected. Otherwise it returns a Conflict *VariableError holding the current
+// Value JSON, and nothing is written.
+// Sequence: seq-set-value.md
+func (v *Variable) SetIfUnchanged(expected any, value any) error {
+	current, err := v.currentJSON()
+	if err != nil {
+		return err
+	}
+	if !jsonEqual(current, expected) {
+		return v.conflict(current, "value is not %v", expected)
+	}
+	return v.Set(value)
+}
+
+// SetIfVersion sets the value only if the variable's Version is version and its
+// value has not changed since the last DetectChanges. Otherwise it returns a
+// Conflict *VariableError holding the current Value JSON, and nothing is written.
+// Sequence: seq-set-value.md
+func (v *Variable) SetIfVersion(version int64, value any) error {
+	current, err := v.currentJSON()
+	if err != nil {
+		return err
+	}
+	if v.Version != version {
+		return v.conflict(current, "version is %d, not %d", v.Version, version)
+	}
+	if !jsonEqual(current, v.ValueJSON) {
+		return v.conflict(current, "value changed since version %d", version)
+	}
+	return v.Set(value)
+}
+
+// currentJSON resolves the variable's value afresh, so changes DetectChanges has
+// not seen yet count. Unreadable variables use the cached ValueJSON.
+func (v *Variable) currentJSON() (any, error) {
+	if !v.IsReadable() {
+		return v.ValueJSON, nil
+	}
+	current, err := v.GetValue()
+	if err != nil {
+		return nil, err
+	}
+	return v.tracker.ToValueJSON(current), nil
+}
+
+// conflict returns a Conflict error holding the current Value JSON.
+func (v *Variable) conflict(current any, msg string, args ...any) *VariableError {
+	e := v.verror(Conflict, "variable %d: "+msg, append([]any{v.ID}, args...)...)
+	e.Current = current
+	return e
+}
+
 // Parent returns the parent variable, or nil if this is a root variable.
 // CRC: crc-Variable.md
 func (v *Variable) Parent() *Variable {
@@ -1527,6 +2206,11 @@ func isValidAccess(access string) bool {
 	return access == "r" || access == "w" || access == "rw" || access == "action"
 }
 
+// invalidAccess returns the BadAccessValue error for an invalid access value.
+func invalidAccess(access string) *VariableError {
+	return verror(BadAccessValue, "invalid access value %q (must be r, w, rw, or action)", access)
+}
+
 // GetAccess returns the access mode of the variable.
 // CRC: crc-Variable.md
 func (v *Variable) GetAccess() string {
@@ -1587,56 +2271,93 @@ func (v *Variable) SetType() {
 }
 
 // SetProperty sets a property. Empty value removes the property.
+// Panics on an invalid path, access value, or access/path combination.
 // Sequence: seq-set-property.md
 func (v *Variable) SetProperty(name, value string) {
+	if err := v.TrySetProperty(name, value); err != nil {
+		panic(fmt.Sprintf("SetProperty: %v", err))
+	}
+}
+
+// TrySetProperty is like SetProperty but returns a *VariableError (BadSetterCall,
+// BadAccessValue, or BadAccessPath) instead of panicking.
+// The property is left unchanged when an error is returned.
+// Sequence: seq-set-property.md
+func (v *Variable) TrySetProperty(name, value string) error {
 	// Parse priority suffix from name
 	baseName, priority := parsePropertyName(name)
+	oldValue := v.Properties[baseName]
 
 	if v.Properties[baseName] == value && v.PropertyPriorities[baseName] == priority {
-		return
-	} else if value == "" {
-		delete(v.Properties, baseName)
-		delete(v.PropertyPriorities, baseName)
-	} else {
-		v.Properties[baseName] = value
-		v.PropertyPriorities[baseName] = priority
+		return nil
 	}
 
-	// Record property change in tracker
-	v.tracker.RecordPropertyChange(v.ID, baseName)
-
-	// Handle special properties
+	// Validate special properties before changing anything
+	var path []any
+	newAccess := v.GetAccess()
 	switch baseName {
 	case "path":
-		v.Path = parsePath(value)
+		path = parsePath(value)
 		// Validate path: setter (_) must be at terminal position
-		if err := validatePath(v.Path); err != nil {
-			panic(fmt.Sprintf("SetProperty: %v", err))
+		if err := validatePath(path); err != nil {
+			return err
 		}
 		// Validate access/path combination
-		if err := validateAccessPath(v.GetAccess(), v.Path); err != nil {
-			panic(fmt.Sprintf("SetProperty: %v", err))
+		if err := validateAccessPath(newAccess, path); err != nil {
+			return err
 		}
-	case "priority":
-		v.ValuePriority = ParsePriority(value)
 	case "access":
 		if value != "" && !isValidAccess(value) {
-			panic(fmt.Sprintf("SetProperty: invalid access value %q (must be r, w, rw, or action)", value))
+			return invalidAccess(value)
 		}
-		newAccess := value
+		newAccess = value
 		if newAccess == "" {
 			newAccess = "rw" // default when removed
 		}
 		// Validate access/path combination
 		if err := validateAccessPath(newAccess, v.Path); err != nil {
-			panic(fmt.Sprintf("SetProperty: %v", err))
+			return err
+		}
+	case "poll":
+		if _, err := parsePoll(value); err != nil {
+			return err
+		}
+	case "timeout":
+		if _, err := parseTimeout(value); err != nil {
+			return err
 		}
+	}
+
+	if value == "" {
+		delete(v.Properties, baseName)
+		delete(v.PropertyPriorities, baseName)
+	} else {
+		v.Properties[baseName] = value
+		v.PropertyPriorities[baseName] = priority
+	}
+
+	// Record property change in tracker
+	v.tracker.recordPropertyChange(v.ID, baseName, oldValue)
+
+	// Handle special properties
+	switch baseName {
+	case "path":
+		v.Path = path
+		v.accessors = nil
+	case "priority":
+		v.ValuePriority = ParsePriority(value)
+	case "access":
 		v.Access = newAccess
+	case "poll":
+		v.pollInterval, _ = parsePoll(value)
+	case "timeout":
+		v.timeout, _ = parseTimeout(value)
 	case "wrapper":
 		// Trigger wrapper update when wrapper property changes
 		v.updateWrapper()
 		v.SetType()
 	}
+	return nil
 }
 
 // parsePropertyName extracts the base name and priority from a property name.
@@ -1,8 +1,13 @@
 package changetracker
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Test types
@@ -117,6 +122,77 @@ func (i *Inner) SetValue(v int) {
 	i.value = v
 }
 
+// Test helpers
+
+// errorType returns the type of a *VariableError, or NoError.
+func errorType(err error) VariableErrorType {
+	var ve *VariableError
+	if errors.As(err, &ve) {
+		return ve.ErrorType
+	}
+	return NoError
+}
+
+// changeIDs returns the IDs of the variables in changes.
+func changeIDs(changes []Change) map[int64]bool {
+	ids := make(map[int64]bool)
+	for _, c := range changes {
+		ids[c.VariableID] = true
+	}
+	return ids
+}
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+	started int // timers requested so far
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.started++
+	c.waiters = append(c.waiters, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// advance moves the clock forward and fires the timers that are due.
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+		} else {
+			w.ch <- c.now
+		}
+	}
+	c.waiters = pending
+}
+
+// timers returns the number of timers requested so far.
+func (c *fakeClock) timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.started
+}
+
 // ============================================================================
 // Priority Tests (test-Priority.md)
 // ============================================================================
@@ -573,6 +649,153 @@ func TestDestroyVariable(t *testing.T) {
 	tr.DestroyVariable(999) // should not panic
 }
 
+// T4.7-T4.9: Subtree destruction
+func TestDestroySubtree(t *testing.T) {
+	tr := NewTracker()
+	person := &Person{Name: "Alice", Address: &Address{City: "Paris"}}
+	root := tr.CreateVariable(person, 0, "", nil)
+	addr := tr.CreateVariable(nil, root.ID, "Address", nil)
+	city := tr.CreateVariable(nil, addr.ID, "City", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+
+	// T4.7: Descendants destroyed depth-first, children before parents
+	destroyed := tr.DestroySubtree(root.ID)
+	if !slices.Equal(destroyed, []int64{city.ID, addr.ID, name.ID, root.ID}) {
+		t.Errorf("expected depth-first order, got %v", destroyed)
+	}
+	if len(tr.Variables()) != 0 {
+		t.Errorf("expected no variables left, got %d", len(tr.Variables()))
+	}
+	// T4.8: Descendants' values unregistered
+	if _, ok := tr.LookupObject(person.Address); ok {
+		t.Error("descendant's object should be unregistered")
+	}
+
+	// T4.9: OrphanChildren keeps descendants
+	tr.OrphanChildren = true
+	root = tr.CreateVariable(person, 0, "", nil)
+	addr = tr.CreateVariable(nil, root.ID, "Address", nil)
+	tr.DestroyVariable(root.ID)
+	if tr.GetVariable(addr.ID) == nil {
+		t.Error("expected orphaned child to remain")
+	}
+	if ids := NewSyncTracker(tr).DestroySubtree(addr.ID); !slices.Equal(ids, []int64{addr.ID}) {
+		t.Errorf("expected DestroySubtree to destroy the orphan, got %v", ids)
+	}
+}
+
+// T11.1-T11.5: Moving variables between parents
+func TestMoveVariable(t *testing.T) {
+	tr := NewTracker()
+	alice := &Person{Name: "Alice", Address: &Address{City: "Paris"}}
+	bob := &Person{Name: "Bob", Address: &Address{City: "Rome"}}
+	r1 := tr.CreateVariable(alice, 0, "", nil)
+	r2 := tr.CreateVariable(bob, 0, "", nil)
+	addr := tr.CreateVariable(nil, r1.ID, "Address", nil)
+	city := tr.CreateVariable(nil, addr.ID, "City", nil)
+	byID := func() map[int64]Change {
+		got := make(map[int64]Change)
+		for _, c := range tr.GetChanges() {
+			got[c.VariableID] = c
+		}
+		return got
+	}
+
+	// T11.1: Same path under another parent: tree updated, subtree re-resolved
+	if err := tr.MoveVariable(addr.ID, r2.ID, "Address"); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	if addr.ParentID != r2.ID || len(r1.ChildIDs) != 0 || !slices.Equal(r2.ChildIDs, []int64{addr.ID}) {
+		t.Errorf("expected addr under r2, got parent %d, r1 %v, r2 %v", addr.ParentID, r1.ChildIDs, r2.ChildIDs)
+	}
+	if city.ValueJSON != "Rome" {
+		t.Errorf("expected descendant re-resolved to Rome, got %v", city.ValueJSON)
+	}
+	changes := byID()
+	if c := changes[addr.ID]; !c.ParentChanged || !c.ValueChanged || len(c.PropertiesChanged) != 0 {
+		t.Errorf("expected parent and value change for addr, got %+v", c)
+	}
+	if c := changes[city.ID]; c.ParentChanged || !c.ValueChanged {
+		t.Errorf("expected only a value change for city, got %+v", c)
+	}
+
+	// T11.2: New path recorded as a path property change
+	if err := tr.MoveVariable(city.ID, r2.ID, "Name"); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	if city.GetProperty("path") != "Name" || city.ValueJSON != "Bob" {
+		t.Errorf("expected city at Name with Bob, got %q %v", city.GetProperty("path"), city.ValueJSON)
+	}
+	if c := byID()[city.ID]; !c.ParentChanged || !c.ValueChanged || !slices.Equal(c.PropertiesChanged, []string{"path"}) {
+		t.Errorf("expected parent, value and path changes, got %+v", c)
+	}
+
+	// T11.3: Moving to the root keeps the value
+	if err := tr.MoveVariable(city.ID, 0, ""); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	if city.ParentID != 0 || len(tr.RootVariables()) != 3 || city.GetProperty("path") != "" || city.ValueJSON != "Bob" {
+		t.Errorf("expected root keeping Bob, got parent %d path %q value %v", city.ParentID, city.GetProperty("path"), city.ValueJSON)
+	}
+	if c := byID()[city.ID]; !c.ParentChanged || c.ValueChanged {
+		t.Errorf("expected only a parent change, got %+v", c)
+	}
+
+	// T11.4: Invalid moves change nothing
+	tests := []struct {
+		id, parent int64
+		path       string
+		want       VariableErrorType
+	}{
+		{99, r1.ID, "Address", NotFound},
+		{addr.ID, 99, "Address", BadParent},
+		{addr.ID, addr.ID, "Address", BadParent},
+		{r2.ID, addr.ID, "", BadParent},
+		{addr.ID, r1.ID, "SetAddress(_)", BadAccessPath},
+		{addr.ID, r1.ID, "Address?access=r", PathError},
+	}
+	for _, tt := range tests {
+		if err := tr.MoveVariable(tt.id, tt.parent, tt.path); errorType(err) != tt.want {
+			t.Errorf("MoveVariable(%d, %d, %q): expected %s, got %v", tt.id, tt.parent, tt.path, tt.want, err)
+		}
+	}
+	if addr.ParentID != r2.ID || r2.ParentID != 0 || len(tr.GetChanges()) != 0 {
+		t.Error("failed moves should change nothing")
+	}
+
+	// T11.5: Versions and cursors see the move; history does not
+	h := tr.NewHistory(0)
+	cursor := tr.NewCursor(0)
+	seq := tr.Seq()
+	if err := NewSyncTracker(tr).MoveVariable(addr.ID, r1.ID, "Address"); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	if changes := tr.ChangesSince(seq); len(changes) != 1 || !changes[0].ParentChanged {
+		t.Errorf("expected ChangesSince to report the move, got %+v", changes)
+	}
+	if changes, _ := cursor.Read(); len(changes) != 1 || !changes[0].ParentChanged || !changes[0].ValueChanged {
+		t.Errorf("expected the cursor to report the move, got %+v", changes)
+	}
+	if h.CanUndo() {
+		t.Error("a move should not be an undo step")
+	}
+
+	// T11.6: Inactive and not-yet-due variables are re-resolved too
+	polled := tr.CreateVariable(nil, r1.ID, "Name?poll=1h", nil)
+	polled.SetActive(false)
+	tr.DetectChanges()
+	tr.GetChanges()
+	if err := tr.MoveVariable(polled.ID, r2.ID, "Name"); err != nil {
+		t.Fatalf("MoveVariable failed: %v", err)
+	}
+	if polled.ValueJSON != "Bob" {
+		t.Errorf("expected the inactive polled variable re-resolved to Bob, got %v", polled.ValueJSON)
+	}
+	if c := byID()[polled.ID]; !c.ParentChanged || !c.ValueChanged {
+		t.Errorf("expected parent and value change, got %+v", c)
+	}
+}
+
 // T5.1-T5.15: DetectChanges
 func TestDetectChanges(t *testing.T) {
 	tr := NewTracker()
@@ -3265,3 +3488,259 @@ func TestWrapper_ReplacementOnDifferentPointer(t *testing.T) {
 		t.Error("W11: New wrapper should be registered")
 	}
 }
+
+// ============================================================================
+// Change Details Tests (test-Change.md)
+// ============================================================================
+
+// CD1.1: details are off by default
+func TestChangeDetails_OffByDefault(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	tr.CreateVariable(nil, root.ID, "Name", nil)
+	p.Name = "Bob"
+	tr.DetectChanges()
+	changes := tr.GetChanges()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if changes[0].OldValueJSON != nil || changes[0].NewValueJSON != nil {
+		t.Error("expected no value details without ChangeDetails")
+	}
+}
+
+// CD1.2: value change carries old and new Value JSON
+func TestChangeDetails_Value(t *testing.T) {
+	tr := NewTracker()
+	tr.ChangeDetails = true
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	tr.CreateVariable(nil, root.ID, "Name", nil)
+
+	p.Name = "Bob"
+	tr.DetectChanges()
+	p.Name = "Carol"
+	tr.DetectChanges()
+	changes := tr.GetChanges()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	c := changes[0]
+	// Old value is from before the first change since the last GetChanges
+	if c.OldValueJSON != "Alice" || c.NewValueJSON != "Carol" {
+		t.Errorf("expected Alice -> Carol, got %v -> %v", c.OldValueJSON, c.NewValueJSON)
+	}
+
+	p.Name = "Dave"
+	tr.DetectChanges()
+	c = tr.GetChanges()[0]
+	if c.OldValueJSON != "Carol" || c.NewValueJSON != "Dave" {
+		t.Errorf("expected Carol -> Dave, got %v -> %v", c.OldValueJSON, c.NewValueJSON)
+	}
+}
+
+// CD1.3: property change carries old and new strings
+func TestChangeDetails_Properties(t *testing.T) {
+	tr := NewTracker()
+	tr.ChangeDetails = true
+	v := tr.CreateVariable(&Person{}, 0, "", map[string]string{"label": "one"})
+	tr.GetChanges()
+
+	v.SetProperty("label", "two")
+	v.SetProperty("label", "three")
+	v.SetProperty("hint", "new")
+	changes := tr.GetChanges()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	c := changes[0]
+	if c.OldProperties["label"] != "one" || c.NewProperties["label"] != "three" {
+		t.Errorf("expected label one -> three, got %q -> %q", c.OldProperties["label"], c.NewProperties["label"])
+	}
+	if old, ok := c.OldProperties["hint"]; !ok || old != "" || c.NewProperties["hint"] != "new" {
+		t.Errorf("expected hint \"\" -> new, got %q -> %q", old, c.NewProperties["hint"])
+	}
+
+	v.SetProperty("hint", "")
+	c = tr.GetChanges()[0]
+	if c.OldProperties["hint"] != "new" || c.NewProperties["hint"] != "" {
+		t.Errorf("expected hint new -> \"\", got %q -> %q", c.OldProperties["hint"], c.NewProperties["hint"])
+	}
+}
+
+// CD1.4: wrapper JSON is included for value changes
+func TestChangeDetails_Wrapper(t *testing.T) {
+	tr := NewTracker()
+	tr.ChangeDetails = true
+	tr.Resolver = &wrapperResolver{tr}
+	type team struct{ Lead *Person }
+	data := &team{Lead: &Person{Name: "Alice"}}
+	root := tr.CreateVariable(data, 0, "", nil)
+	v := tr.CreateVariable(nil, root.ID, "Lead?wrapper=test", nil)
+	oldValue, oldWrapper := v.ValueJSON, v.WrapperJSON
+	if oldWrapper == nil {
+		t.Fatal("expected wrapper for initial value")
+	}
+	tr.GetChanges()
+
+	data.Lead = &Person{Name: "Bob"}
+	tr.DetectChanges()
+	changes := tr.GetChanges()
+	if len(changes) != 1 || !changes[0].ValueChanged {
+		t.Fatalf("expected 1 value change, got %v", changes)
+	}
+	c := changes[0]
+	if c.OldValueJSON != oldValue || c.NewValueJSON != v.ValueJSON {
+		t.Errorf("expected value %v -> %v, got %v -> %v", oldValue, v.ValueJSON, c.OldValueJSON, c.NewValueJSON)
+	}
+	if c.OldWrapperJSON != oldWrapper || c.NewWrapperJSON != v.WrapperJSON {
+		t.Errorf("expected wrapper %v -> %v, got %v -> %v", oldWrapper, v.WrapperJSON, c.OldWrapperJSON, c.NewWrapperJSON)
+	}
+}
+
+// ============================================================================
+// Validation Error Tests (test-Variable.md)
+// ============================================================================
+
+// VE1.1: TryCreateVariable returns typed errors and leaves the tree unchanged
+func TestTryCreateVariable_Errors(t *testing.T) {
+	tr := NewTracker()
+	outer := &Outer{inner: &Inner{value: 42}}
+	root := tr.CreateVariable(outer, 0, "", nil)
+	counterRoot := tr.CreateVariable(&Counter{value: 1}, 0, "", nil)
+
+	tests := []struct {
+		parentID int64
+		value    any
+		path     string
+		want     VariableErrorType
+	}{
+		{root.ID, nil, "SetInner(_).value", BadSetterCall},
+		{counterRoot.ID, nil, "Value()?access=rx", BadAccessValue},
+		{counterRoot.ID, nil, "Value()?access=w", BadAccessPath},
+		{counterRoot.ID, nil, "SetValue(_)?access=r", BadAccessPath},
+		{counterRoot.ID, 5, "Value()?access=r", BadChildValue},
+	}
+	for _, tc := range tests {
+		v, err := tr.TryCreateVariable(tc.value, tc.parentID, tc.path, nil)
+		if v != nil || errorType(err) != tc.want {
+			t.Errorf("%q: expected %v, got %v (%v)", tc.path, tc.want, v, err)
+		}
+	}
+	if len(tr.Variables()) != 2 || len(root.ChildIDs) != 0 || len(counterRoot.ChildIDs) != 0 {
+		t.Errorf("failed creates changed the tree: %d variables", len(tr.Variables()))
+	}
+	if v, err := tr.TryCreateVariable(nil, counterRoot.ID, "Value()?access=r", nil); err != nil || v == nil {
+		t.Errorf("expected valid create to succeed, got %v", err)
+	}
+}
+
+// VE1.2: TrySetProperty returns typed errors and leaves the property unchanged
+func TestTrySetProperty_Errors(t *testing.T) {
+	tr := NewTracker()
+	root := tr.CreateVariable(&Counter{value: 1}, 0, "", nil)
+	v := tr.CreateVariable(nil, root.ID, "Value()?access=r", nil)
+	tr.GetChanges()
+
+	tests := []struct {
+		name, value string
+		want        VariableErrorType
+	}{
+		{"access", "rx", BadAccessValue},
+		{"access", "w", BadAccessPath},
+		{"path", "SetValue(_)", BadAccessPath},
+		{"path", "SetValue(_).x", BadSetterCall},
+	}
+	for _, tc := range tests {
+		if err := v.TrySetProperty(tc.name, tc.value); errorType(err) != tc.want {
+			t.Errorf("%s=%q: expected %v, got %v", tc.name, tc.value, tc.want, err)
+		}
+	}
+	if v.Access != "r" || v.Properties["path"] != "Value()" || len(v.Path) != 1 {
+		t.Errorf("failed sets changed the variable: access %q, path %q", v.Access, v.Properties["path"])
+	}
+	if changes := tr.GetChanges(); len(changes) != 0 {
+		t.Errorf("failed sets recorded changes: %v", changes)
+	}
+	if err := v.TrySetProperty("access", "rw"); err != nil || v.Access != "rw" {
+		t.Errorf("expected valid set to succeed, got %v (access %q)", err, v.Access)
+	}
+}
+
+// VE1.3: panicking variants report the same errors
+func TestSetProperty_PanicsWithError(t *testing.T) {
+	tr := NewTracker()
+	v := tr.CreateVariable(&Counter{}, 0, "", nil)
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "BadAccessValue") {
+			t.Errorf("expected BadAccessValue panic, got %v", r)
+		}
+	}()
+	v.SetProperty("access", "rx")
+}
+
+// ============================================================================
+// Conditional Set Tests (test-Variable.md)
+// ============================================================================
+
+// CS1.1: SetIfUnchanged writes only when the caller's view is current
+func TestSetIfUnchanged(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	if err := age.SetIfUnchanged(30.0, 31); err != nil || p.Age != 31 {
+		t.Errorf("expected set to succeed, got %v (age %d)", err, p.Age)
+	}
+	// Not yet detected: the fresh value still counts
+	err := age.SetIfUnchanged(30, 40)
+	ve, ok := err.(*VariableError)
+	if !ok || ve.ErrorType != Conflict || ve.Current != 31 || p.Age != 31 {
+		t.Errorf("expected Conflict with current 31, got %v (age %d)", err, p.Age)
+	}
+	if age.Error != err {
+		t.Errorf("expected Error to be set, got %v", age.Error)
+	}
+}
+
+// CS1.2: SetIfVersion rejects stale versions and undetected changes
+func TestSetIfVersion(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice"}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	seen := name.Version
+	if err := name.SetIfVersion(seen, "Bob"); err != nil || p.Name != "Bob" {
+		t.Errorf("expected set to succeed, got %v (name %q)", err, p.Name)
+	}
+	if err := name.SetIfVersion(seen, "Carol"); errorType(err) != Conflict || err.(*VariableError).Current != "Bob" {
+		t.Errorf("expected Conflict for stale version, got %v", err)
+	}
+	p.Name = "Dan"
+	if err := name.SetIfVersion(name.Version, "Carol"); errorType(err) != Conflict || p.Name != "Dan" {
+		t.Errorf("expected Conflict for undetected change, got %v (name %q)", err, p.Name)
+	}
+	tr.DetectChanges()
+	if err := name.SetIfVersion(name.Version, "Carol"); err != nil || p.Name != "Carol" {
+		t.Errorf("expected set with current version to succeed, got %v (name %q)", err, p.Name)
+	}
+}
+
+// CS1.3: SyncTracker conditional sets
+func TestSyncTracker_SetIf(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+	version := name.Version
+	if err := s.SetIfUnchanged(name.ID, "Alice", "Bob"); err != nil {
+		t.Errorf("expected set to succeed, got %v", err)
+	}
+	if err := s.SetIfVersion(name.ID, version, "Carol"); errorType(err) != Conflict {
+		t.Errorf("expected Conflict, got %v", err)
+	}
+	if err := s.SetIfUnchanged(99, nil, "x"); errorType(err) != NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// CRC: crc-Transaction.md
+// Spec: api.md
+package changetracker
+
+import "errors"
+
+// Tx collects the sets of a transaction. Sets are validated when they are
+// queued and applied together after the transaction function returns.
+// CRC: crc-Transaction.md
+type Tx struct {
+	tracker *Tracker
+	ops     []txOp
+}
+
+// txOp is a queued set, with what is needed to undo it once applied.
+type txOp struct {
+	v      *Variable
+	value  any
+	prev   any // value read through the resolver before the set
+	cached any // v.Value before the set
+}
+
+// Transaction runs fn and then applies the sets it queued on tx, in order.
+// If fn returns an error nothing is applied. If a set fails (or panics), the
+// sets already applied are undone in reverse order by writing back the values
+// read through the resolver before each set. Value changes are recorded only
+// when every set succeeds, so consumers never see a partial transaction.
+// Returns fn's error or the failing set's error, joined with any restore errors.
+// Sequence: seq-transaction.md
+func (t *Tracker) Transaction(fn func(tx *Tx) error) error {
+	tx := &Tx{tracker: t}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.apply(); err != nil {
+		return err
+	}
+	tx.commit()
+	return nil
+}
+
+// Set queues setting a variable's value. The variable must exist, be writable,
+// and be readable so its previous value can be restored; its path must
+// currently resolve. Returns the validation error, if any; the set is not
+// queued then, and fn decides whether to abort by returning an error.
+func (tx *Tx) Set(varID int64, value any) error {
+	v := tx.tracker.GetVariable(varID)
+	if v == nil {
+		return verror(NotFound, "variable %d not found", varID)
+	}
+	if !v.IsWritable() {
+		return v.verror(BadAccess, "cannot Set on read-only variable (access: %q)", v.GetAccess())
+	}
+	if !v.IsReadable() {
+		return v.verror(BadAccess, "cannot roll back %q variable %d: its value cannot be read", v.GetAccess(), varID)
+	}
+	if len(v.Path) > 0 {
+		if last := v.Path[len(v.Path)-1]; isSetterCall(last) {
+			return v.verror(BadAccess, "cannot roll back variable %d: its path ends in a setter", varID)
+		}
+	}
+	if _, err := v.GetValue(); err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, txOp{v: v, value: value})
+	return nil
+}
+
+// apply performs the queued sets, rolling back on failure.
+// Sequence: seq-transaction.md
+func (tx *Tx) apply() (err error) {
+	applied := 0
+	defer func() {
+		if r := recover(); r != nil {
+			tx.ops[applied].v.Value = tx.ops[applied].cached
+			tx.tracker.indexValue(tx.ops[applied].v)
+			tx.rollback(applied)
+			panic(r)
+		}
+	}()
+	for i := range tx.ops {
+		op := &tx.ops[i]
+		op.cached = op.v.Value
+		if op.prev, err = op.v.GetValue(); err != nil {
+			return errors.Join(err, tx.rollback(applied))
+		}
+		if err = op.v.setValue(op.value); err != nil {
+			op.v.Value = op.cached
+			tx.tracker.indexValue(op.v)
+			return errors.Join(err, tx.rollback(applied))
+		}
+		applied++
+	}
+	return nil
+}
+
+// commit records value changes for the variables the transaction set.
+// With history, the transaction is one undo step.
+func (tx *Tx) commit() {
+	if h := tx.tracker.history; h != nil {
+		h.Begin()
+		defer h.End()
+	}
+	for _, op := range tx.ops {
+		tx.tracker.refresh(op.v)
+	}
+}
+
+// rollback undoes the first n applied sets in reverse order.
+func (tx *Tx) rollback(n int) error {
+	var errs []error
+	for i := n - 1; i >= 0; i-- {
+		op := tx.ops[i]
+		if err := op.v.setValue(op.prev); err != nil {
+			errs = append(errs, err)
+		}
+		op.v.Value = op.cached
+		tx.tracker.indexValue(op.v)
+	}
+	return errors.Join(errs...)
+}
+
+// setValue writes value without touching ValueJSON, keeping the cached
+// navigation value current for descendants, as Set does.
+func (v *Variable) setValue(value any) error {
+	v.Value = value
+	v.tracker.indexValue(v)
+	if len(v.Path) == 0 {
+		return nil
+	}
+	return v.write(value)
+}
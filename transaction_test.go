@@ -0,0 +1,159 @@
+package changetracker
+
+import "testing"
+
+// ============================================================================
+// Transaction Tests (test-Transaction.md)
+// ============================================================================
+
+// TX1.1: committed sets apply together and are recorded at commit
+func TestTransaction_Commit(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	counter := &Counter{value: 1}
+	root := tr.CreateVariable(p, 0, "", nil)
+	croot := tr.CreateVariable(counter, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	count := tr.CreateVariable(nil, croot.ID, "Count()", nil)
+	readOnly := tr.CreateVariable(nil, croot.ID, "Value()?access=r", nil)
+	tr.GetChanges()
+
+	tr.ChangeDetails = true
+	err := tr.Transaction(func(tx *Tx) error {
+		tx.Set(name.ID, "Bob")
+		tx.Set(age.ID, 31)
+		if len(tr.GetChanges()) != 0 || p.Name != "Alice" {
+			t.Error("expected nothing applied before fn returns")
+		}
+		return tx.Set(count.ID, 5)
+	})
+	if err != nil || p.Name != "Bob" || p.Age != 31 || counter.value != 5 {
+		t.Fatalf("expected all sets applied, got %v (%+v, %d)", err, p, counter.value)
+	}
+	changes := tr.GetChanges()
+	if ids := changeIDs(changes); len(ids) != 3 {
+		t.Errorf("expected 3 recorded changes, got %v", changes)
+	}
+	for _, c := range changes {
+		if c.VariableID == name.ID && (c.OldValueJSON != "Alice" || c.NewValueJSON != "Bob") {
+			t.Errorf("expected Alice -> Bob, got %+v", c)
+		}
+	}
+	// Only variables the transaction did not set remain to be detected
+	tr.DetectChanges()
+	if ids := changeIDs(tr.GetChanges()); len(ids) != 1 || !ids[readOnly.ID] {
+		t.Errorf("expected only %d to be detected, got %v", readOnly.ID, ids)
+	}
+}
+
+// TX1.2: validation errors are reported when queuing; fn decides to abort
+func TestTransaction_Validation(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	counter := &Counter{value: 1}
+	root := tr.CreateVariable(p, 0, "", nil)
+	croot := tr.CreateVariable(counter, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	readOnly := tr.CreateVariable(nil, croot.ID, "Value()?access=r", nil)
+	tr.GetChanges()
+
+	var errs []error
+	err := tr.Transaction(func(tx *Tx) error {
+		tx.Set(name.ID, "Bob")
+		errs = append(errs, tx.Set(99, 1), tx.Set(readOnly.ID, 2))
+		return errs[1]
+	})
+	if errorType(errs[0]) != NotFound || errorType(errs[1]) != BadAccess || err != errs[1] {
+		t.Errorf("expected NotFound and BadAccess, got %v (returned %v)", errs, err)
+	}
+	if p.Name != "Alice" {
+		t.Errorf("expected nothing applied, got %q", p.Name)
+	}
+	w := tr.CreateVariable(nil, root.ID, "Name?access=w", nil)
+	tr.Transaction(func(tx *Tx) error {
+		if err := tx.Set(w.ID, "x"); errorType(err) != BadAccess {
+			t.Errorf("expected BadAccess for write-only variable, got %v", err)
+		}
+		return nil
+	})
+}
+
+// TX1.3: a failing set rolls back earlier sets and records nothing
+func TestTransaction_Rollback(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	counter := &Counter{value: 1}
+	root := tr.CreateVariable(p, 0, "", nil)
+	croot := tr.CreateVariable(counter, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	count := tr.CreateVariable(nil, croot.ID, "Count()", nil)
+	tr.GetChanges()
+
+	err := tr.Transaction(func(tx *Tx) error {
+		tx.Set(name.ID, "Bob")
+		tx.Set(count.ID, 7)
+		return tx.Set(age.ID, "old") // type mismatch, found when applied
+	})
+	if errorType(err) != PathError {
+		t.Errorf("expected PathError, got %v", err)
+	}
+	if p.Name != "Alice" || counter.value != 1 || name.Value != "Alice" {
+		t.Errorf("expected rollback, got %q, %d, cached %v", p.Name, counter.value, name.Value)
+	}
+	if changes := tr.GetChanges(); len(changes) != 0 || tr.DetectChanges() {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+type fuse struct{ level int }
+
+func (f *fuse) Level(args ...int) int {
+	if len(args) > 0 {
+		if args[0] < 0 {
+			panic("negative level")
+		}
+		f.level = args[0]
+	}
+	return f.level
+}
+
+// TX1.4: a panicking set rolls back earlier sets before the panic propagates
+func TestTransaction_Panic(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	tr.GetChanges()
+
+	fz := &fuse{level: 1}
+	level := tr.CreateVariable(nil, tr.CreateVariable(fz, 0, "", nil).ID, "Level()", nil)
+	defer func() {
+		if r := recover(); r != "negative level" {
+			t.Errorf("expected panic to propagate, got %v", r)
+		}
+		if p.Name != "Alice" || fz.level != 1 {
+			t.Errorf("expected rollback, got %q, %d", p.Name, fz.level)
+		}
+	}()
+	tr.Transaction(func(tx *Tx) error {
+		tx.Set(name.ID, "Bob")
+		tx.Set(level.ID, 2)
+		return tx.Set(level.ID, -1)
+	})
+}
+
+// TX1.5: SyncTracker transactions
+func TestSyncTracker_Transaction(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+	if err := s.Transaction(func(tx *Tx) error { return tx.Set(name.ID, "Bob") }); err != nil {
+		t.Fatal(err)
+	}
+	if changes := s.GetChanges(); len(changes) != 1 || p.Name != "Bob" {
+		t.Errorf("expected committed change, got %v (%q)", changes, p.Name)
+	}
+}
@@ -0,0 +1,172 @@
+// CRC: crc-Version.md
+// Spec: api.md
+package changetracker
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// Seq returns the tracker's change sequence number. It increases with every
+// recorded value or property change and every variable creation or
+// destruction, so a consumer
+// can remember it and later ask what changed since.
+func (t *Tracker) Seq() int64 {
+	return t.seq
+}
+
+// versionCreated stamps a new variable: its value and all its properties count
+// as changed at creation.
+func (t *Tracker) versionCreated(v *Variable) {
+	t.seq++
+	delete(t.destroyed, v.ID) // the ID is in use again
+	v.Version = t.seq
+	v.valueVersion = t.seq
+	v.propVersions = make(map[string]int64, len(v.Properties))
+	for name := range v.Properties {
+		v.propVersions[name] = t.seq
+	}
+}
+
+// versionValue stamps a value change.
+func (t *Tracker) versionValue(v *Variable) {
+	t.seq++
+	v.Version = t.seq
+	v.valueVersion = t.seq
+}
+
+// versionParent stamps a parent change.
+func (t *Tracker) versionParent(v *Variable) {
+	t.seq++
+	v.Version = t.seq
+	v.parentVersion = t.seq
+}
+
+// versionProperty stamps a property change. Removed properties keep their
+// version so they are reported as changed.
+func (t *Tracker) versionProperty(v *Variable, name string) {
+	t.seq++
+	v.Version = t.seq
+	if v.propVersions == nil {
+		v.propVersions = make(map[string]int64)
+	}
+	v.propVersions[name] = t.seq
+}
+
+// versionDestroyed stamps a destruction. It is remembered for ChangesSince
+// only while checkpoints exist.
+func (t *Tracker) versionDestroyed(id int64) {
+	t.seq++
+	if len(t.checkpoints) > 0 {
+		t.destroyed[id] = t.seq
+	}
+}
+
+// Checkpoint is a version consumer: it holds the sequence number a client
+// resynchronizes from with ChangesSince. The tracker remembers destroyed
+// variables only while checkpoints exist, and forgets them once every
+// checkpoint has moved past them.
+// CRC: crc-Version.md
+type Checkpoint struct {
+	tracker *Tracker
+	seq     int64
+}
+
+// NewCheckpoint registers a checkpoint at the current sequence number.
+func (t *Tracker) NewCheckpoint() *Checkpoint {
+	c := &Checkpoint{tracker: t, seq: t.seq}
+	t.checkpoints = append(t.checkpoints, c)
+	return c
+}
+
+// Seq returns the checkpoint's sequence number.
+func (c *Checkpoint) Seq() int64 {
+	return c.seq
+}
+
+// Changes returns ChangesSince(Seq()) and moves the checkpoint to the current
+// sequence number.
+func (c *Checkpoint) Changes() []Change {
+	changes := c.tracker.ChangesSince(c.seq)
+	c.seq = c.tracker.seq
+	c.tracker.pruneDestroyed()
+	return changes
+}
+
+// Close unregisters the checkpoint.
+func (c *Checkpoint) Close() {
+	t := c.tracker
+	t.checkpoints = slices.DeleteFunc(t.checkpoints, func(other *Checkpoint) bool { return other == c })
+	t.pruneDestroyed()
+}
+
+// pruneDestroyed forgets the destructions no checkpoint can ask for anymore.
+func (t *Tracker) pruneDestroyed() {
+	if len(t.checkpoints) == 0 {
+		clear(t.destroyed)
+		return
+	}
+	oldest := slices.MinFunc(t.checkpoints, func(a, b *Checkpoint) int {
+		return cmp.Compare(a.seq, b.seq)
+	}).seq
+	maps.DeleteFunc(t.destroyed, func(_ int64, version int64) bool {
+		return version <= oldest
+	})
+}
+
+// VariablesChangedSince returns the variables created or changed after seq,
+// in the order of their last change. Destroyed variables are not reported;
+// ChangesSince reports them.
+// CRC: crc-Version.md
+func (t *Tracker) VariablesChangedSince(seq int64) []*Variable {
+	var result []*Variable
+	for _, v := range t.variables {
+		if v.Version > seq {
+			result = append(result, v)
+		}
+	}
+	slices.SortFunc(result, func(a, b *Variable) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return result
+}
+
+// ChangesSince returns the value and property changes made after seq, one
+// entry per variable and priority like GetChanges, without old values.
+// Variables created after seq report their value and all their properties.
+// Variables destroyed after seq follow, in the order of their destruction,
+// with Destroyed set, unless their ID was reused since; destructions are
+// remembered only while a Checkpoint at or before seq exists. It does not
+// affect GetChanges, subscriptions, or cursors.
+// CRC: crc-Version.md
+func (t *Tracker) ChangesSince(seq int64) []Change {
+	rec := newChangeRecord()
+	for _, v := range t.VariablesChangedSince(seq) {
+		if v.valueVersion > seq {
+			rec.values[v.ID] = true
+		}
+		if v.parentVersion > seq {
+			rec.parents[v.ID] = true
+		}
+		for name, version := range v.propVersions {
+			if version > seq {
+				rec.recordProperty(v.ID, name, "", false)
+			}
+		}
+	}
+	changes := t.buildChanges(nil, *rec, false)
+	var destroyed []int64
+	for id, version := range t.destroyed {
+		if version > seq {
+			destroyed = append(destroyed, id)
+		}
+	}
+	slices.SortFunc(destroyed, func(a, b int64) int {
+		return cmp.Compare(t.destroyed[a], t.destroyed[b])
+	})
+	for _, id := range destroyed {
+		changes = append(changes, Change{VariableID: id, Destroyed: true})
+	}
+	return changes
+}
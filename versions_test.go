@@ -0,0 +1,197 @@
+package changetracker
+
+import "testing"
+
+// ============================================================================
+// Version Tests (test-Version.md)
+// ============================================================================
+
+// VR1.1: creation and changes bump the tracker sequence and the variable version
+func TestVersions_Bump(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	created := tr.Seq()
+	if bName.Version != created || aName.Version >= bName.Version {
+		t.Errorf("expected creation order versions, got %d, %d (seq %d)", aName.Version, bName.Version, created)
+	}
+	people[0].Name = "Ann"
+	tr.DetectChanges()
+	if tr.Seq() != created+1 || aName.Version != created+1 {
+		t.Errorf("expected value change at %d, got seq %d version %d", created+1, tr.Seq(), aName.Version)
+	}
+	tr.DetectChanges()
+	bName.SetProperty("label", "x")
+	if tr.Seq() != created+2 || bName.Version != created+2 || aName.Version != created+1 {
+		t.Errorf("expected property change at %d, got seq %d versions %d, %d", created+2, tr.Seq(), aName.Version, bName.Version)
+	}
+}
+
+// VR1.2: queries report what changed after a sequence number
+func TestVersions_Since(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	aName := tr.CreateVariable(nil, a.ID, "Name", nil)
+	bName := tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	tr.ChangeDetails = true
+	seq := tr.Seq()
+	bName.SetProperty("label", "x")
+	people[0].Name = "Ann"
+	tr.DetectChanges()
+	bName.SetProperty("label", "")
+	tr.GetChanges()
+
+	vars := tr.VariablesChangedSince(seq)
+	if len(vars) != 2 || vars[0] != aName || vars[1] != bName {
+		t.Errorf("expected aName then bName, got %v", vars)
+	}
+	changes := tr.ChangesSince(seq)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	for _, c := range changes {
+		if c.OldProperties != nil || c.OldValueJSON != nil {
+			t.Errorf("expected no details, got %+v", c)
+		}
+		switch c.VariableID {
+		case aName.ID:
+			if !c.ValueChanged || len(c.PropertiesChanged) != 0 {
+				t.Errorf("expected value change for aName, got %+v", c)
+			}
+		case bName.ID:
+			if c.ValueChanged || len(c.PropertiesChanged) != 1 || c.PropertiesChanged[0] != "label" {
+				t.Errorf("expected removed label for bName, got %+v", c)
+			}
+		}
+	}
+	if len(tr.ChangesSince(tr.Seq())) != 0 || len(tr.VariablesChangedSince(tr.Seq())) != 0 {
+		t.Error("expected nothing after the current sequence number")
+	}
+}
+
+// VR1.3: variables created after seq report their value and all properties
+func TestVersions_Created(t *testing.T) {
+	tr := NewTracker()
+	people := []*Person{{Name: "Alice"}, {Name: "Bob"}}
+	root := tr.CreateVariable(people, 0, "", nil)
+	a := tr.CreateVariable(nil, root.ID, "0", nil)
+	b := tr.CreateVariable(nil, root.ID, "1", nil)
+	tr.CreateVariable(nil, a.ID, "Name", nil)
+	tr.CreateVariable(nil, b.ID, "Name?priority=high", nil)
+	tr.GetChanges()
+
+	seq := tr.Seq()
+	age := tr.CreateVariable(nil, a.ID, "Age?priority=high", map[string]string{"label": "Age"})
+	changes := tr.ChangesSince(seq)
+	if len(changes) != 2 {
+		t.Fatalf("expected high and medium changes, got %+v", changes)
+	}
+	if c := changes[0]; c.VariableID != age.ID || c.Priority != PriorityHigh || !c.ValueChanged {
+		t.Errorf("expected high value change, got %+v", c)
+	}
+	if props := changeProps(changes); !props["label"] || !props["path"] || !props["priority"] {
+		t.Errorf("expected all properties, got %v", props)
+	}
+	tr.DestroyVariable(age.ID)
+	if changes := tr.ChangesSince(seq); len(changes) != 0 || len(tr.destroyed) != 0 {
+		t.Errorf("expected destructions to be dropped without checkpoints, got %+v", changes)
+	}
+}
+
+func changeProps(changes []Change) map[string]bool {
+	props := make(map[string]bool)
+	for _, c := range changes {
+		for _, name := range c.PropertiesChanged {
+			props[name] = true
+		}
+	}
+	return props
+}
+
+// VR1.4: SyncTracker queries
+func TestVersions_Sync(t *testing.T) {
+	s := NewSyncTracker(nil)
+	p := &Person{Name: "Alice"}
+	root := s.CreateVariable(p, 0, "", nil)
+	name := s.CreateVariable(nil, root.ID, "Name", nil)
+	seq := s.Seq()
+	s.Do(func(*Tracker) { p.Name = "Bob" })
+	s.DetectChanges()
+	if ids := s.VariablesChangedSince(seq); len(ids) != 1 || ids[0] != name.ID {
+		t.Errorf("expected [%d], got %v", name.ID, ids)
+	}
+	if changes := s.ChangesSince(seq); len(changes) != 1 || changes[0].VariableID != name.ID {
+		t.Errorf("expected change for %d, got %v", name.ID, changes)
+	}
+	c := s.NewCheckpoint()
+	s.DestroyVariable(name.ID)
+	if changes := s.CheckpointChanges(c); len(changes) != 1 || !changes[0].Destroyed {
+		t.Errorf("expected the destruction of %d, got %v", name.ID, changes)
+	}
+	s.CloseCheckpoint(c)
+}
+
+// VR1.5: destroyed variables are reported while checkpoints need them
+func TestVersions_Destroyed(t *testing.T) {
+	tr := NewTracker()
+	p := &Person{Name: "Alice", Age: 30}
+	root := tr.CreateVariable(p, 0, "", nil)
+	name := tr.CreateVariable(nil, root.ID, "Name", nil)
+	age := tr.CreateVariable(nil, root.ID, "Age", nil)
+	early := tr.NewCheckpoint()
+	tr.DestroyVariable(age.ID)
+	late := tr.NewCheckpoint()
+	tr.DestroyVariable(name.ID)
+	if tr.Seq() != early.Seq()+2 || len(tr.VariablesChangedSince(early.Seq())) != 0 {
+		t.Errorf("expected destructions to bump seq only, got %d", tr.Seq())
+	}
+	changes := tr.ChangesSince(early.Seq())
+	if len(changes) != 2 || changes[0].VariableID != age.ID || changes[1].VariableID != name.ID {
+		t.Fatalf("expected age then name, got %+v", changes)
+	}
+	for _, c := range changes {
+		if !c.Destroyed || c.ValueChanged || len(c.PropertiesChanged) != 0 {
+			t.Errorf("expected a bare destruction, got %+v", c)
+		}
+	}
+	if changes := late.Changes(); len(changes) != 1 || changes[0].VariableID != name.ID || late.Seq() != tr.Seq() {
+		t.Errorf("expected only name after the late checkpoint, got %+v", changes)
+	}
+	if len(tr.destroyed) != 2 {
+		t.Errorf("expected the early checkpoint to keep both, got %v", tr.destroyed)
+	}
+	if changes := early.Changes(); len(changes) != 2 || len(tr.destroyed) != 0 {
+		t.Errorf("expected both, then nothing kept, got %+v, %v", changes, tr.destroyed)
+	}
+
+	// A reused ID reports its creation
+	title := tr.CreateVariable(nil, root.ID, "Name", nil)
+	tr.DestroyVariable(title.ID)
+	again := tr.CreateVariableWithId(title.ID, nil, root.ID, "Name", nil)
+	changes = early.Changes()
+	if len(changes) != 1 || changes[0].VariableID != again.ID || changes[0].Destroyed || !changes[0].ValueChanged {
+		t.Errorf("expected a reused ID to report its creation, got %+v", changes)
+	}
+
+	// Without checkpoints nothing is kept
+	early.Close()
+	tr.DestroyVariable(again.ID)
+	if len(tr.destroyed) != 1 {
+		t.Errorf("expected the late checkpoint to keep the destruction, got %v", tr.destroyed)
+	}
+	late.Close()
+	if len(tr.destroyed) != 0 || len(tr.checkpoints) != 0 {
+		t.Errorf("expected nothing kept, got %v", tr.destroyed)
+	}
+}